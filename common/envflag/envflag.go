@@ -0,0 +1,123 @@
+// Package envflag 환경변수와 .env 파일로 플래그 값을 채우는 헬퍼
+//
+// 우선순위: 명시적 플래그 > 환경변수 > .env 파일 > 기본값
+package envflag
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LoadDotEnv .env 파일을 읽어 KEY=VALUE 맵으로 반환. 파일이 없으면 빈 맵 반환
+func LoadDotEnv(filename string) (map[string]string, error) {
+	values := make(map[string]string)
+	if filename == "" {
+		return values, nil
+	}
+
+	// 상대 경로 공격 방지 (a..b.env 같은 이름은 허용)
+	if hasParentRef(filename) {
+		return nil, errors.New("invalid file path: relative path not allowed")
+	}
+
+	file, err := os.Open(filepath.Clean(filename))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return values, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		// 빈 줄과 주석 무시
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: KEY=VALUE 형식이 아님", filename, lineNo)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		// 따옴표 제거
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// hasParentRef 경로에 ".." 구성 요소가 있으면 true
+func hasParentRef(filename string) bool {
+	for _, part := range strings.FieldsFunc(filename, func(r rune) bool { return r == '/' || r == filepath.Separator }) {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// Bind names에 있는 플래그 중 명시적으로 지정되지 않은 것을 환경변수 또는 .env 파일 값으로 채움
+// names는 플래그 이름 -> 환경변수 이름. 여기에 없는 플래그는 환경변수를 보지 않으므로
+// 셸에 남아 있는 DELETE 같은 변수가 작업 종류를 바꾸지 못함 (접속 정보 같은 설정 플래그만 넣을 것)
+// fs.Parse 이후에 호출해야 함
+func Bind(fs *flag.FlagSet, dotenvPath string, names map[string]string) error {
+	dotenv, err := LoadDotEnv(dotenvPath)
+	if err != nil {
+		return fmt.Errorf(".env 파일 읽기 실패 -> %w", err)
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	flagNames := make([]string, 0, len(names))
+	for name := range names {
+		flagNames = append(flagNames, name)
+	}
+	sort.Strings(flagNames)
+
+	for _, name := range flagNames {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("알 수 없는 플래그: %s", name)
+		}
+		if explicit[name] {
+			continue
+		}
+
+		key := names[name]
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			value, ok = dotenv[key]
+		}
+		if !ok {
+			continue
+		}
+
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("%s 값이 올바르지 않음 -> %w", key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package envflag
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBind(t *testing.T) {
+	dotenv := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(dotenv, []byte("# 주석\nDB_HOST=dotenv-host\nexport DB_NAME='dotenv-db'\nDELETE=true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "dotenv fills allowlisted flags only",
+			want: map[string]string{"db-host": "dotenv-host", "db-name": "dotenv-db", "db-port": "5432", "delete": "false"},
+		},
+		{
+			name: "environment beats dotenv",
+			env:  map[string]string{"DB_HOST": "env-host", "DB_PORT": "6543"},
+			want: map[string]string{"db-host": "env-host", "db-name": "dotenv-db", "db-port": "6543"},
+		},
+		{
+			name: "explicit flag beats environment",
+			args: []string{"-db-host=flag-host"},
+			env:  map[string]string{"DB_HOST": "env-host"},
+			want: map[string]string{"db-host": "flag-host"},
+		},
+		{
+			name: "stray destructive variable is ignored",
+			env:  map[string]string{"DELETE": "true", "VIDEO_WHERE": "1=1"},
+			want: map[string]string{"delete": "false", "video-where": ""},
+		},
+		{
+			name:    "invalid value",
+			env:     map[string]string{"DB_PORT": "not-a-port"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.String("db-host", "localhost", "")
+			fs.Int("db-port", 5432, "")
+			fs.String("db-name", "postgres", "")
+			fs.Bool("delete", false, "")
+			fs.String("video-where", "", "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			err := Bind(fs, dotenv, map[string]string{
+				"db-host": "DB_HOST",
+				"db-port": "DB_PORT",
+				"db-name": "DB_NAME",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Bind error = %v, wantErr %v", err, tt.wantErr)
+			}
+			for name, want := range tt.want {
+				if got := fs.Lookup(name).Value.String(); got != want {
+					t.Errorf("-%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBindUnknownFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := Bind(fs, "", map[string]string{"db-host": "DB_HOST"}); err == nil {
+		t.Error("Bind with an unknown flag succeeded, want error")
+	}
+}
+
+func TestLoadDotEnvPath(t *testing.T) {
+	dir := t.TempDir()
+	dotted := filepath.Join(dir, "prod..backup.env")
+	if err := os.WriteFile(dotted, []byte("DB_HOST=backup-host\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		want     string // DB_HOST
+		wantErr  bool
+	}{
+		{"dots inside a name are allowed", dotted, "backup-host", false},
+		{"missing file is empty", filepath.Join(dir, "missing.env"), "", false},
+		{"parent directory is rejected", "../.env", "", true},
+		{"parent directory in the middle is rejected", dir + "/sub/../.env", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := LoadDotEnv(tt.filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadDotEnv(%q) error = %v, wantErr %v", tt.filename, err, tt.wantErr)
+			}
+			if got := values["DB_HOST"]; got != tt.want {
+				t.Errorf("DB_HOST = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
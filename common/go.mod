@@ -0,0 +1,3 @@
+module github.com/unboxerscorp/utility/common
+
+go 1.25.1
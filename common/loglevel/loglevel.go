@@ -0,0 +1,88 @@
+// Package loglevel -log-level 플래그로 진행 로그의 출력 수준을 거르는 헬퍼
+//
+// 최종 결과/보고와 치명적 오류(log.Fatal)는 수준과 무관하게 항상 출력하고,
+// 파일별 진행 로그만 이 패키지를 거쳐 출력함
+package loglevel
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level 로그 수준
+type Level int32
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+// Names -log-level 플래그에 쓸 수 있는 값
+const Names = "debug|info|warn|error"
+
+var (
+	current atomic.Int32
+	printf  = log.Printf
+)
+
+func init() {
+	current.Store(int32(Info))
+}
+
+// Parse 수준 이름을 Level로 변환 (대소문자 무시)
+func Parse(name string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return Debug, nil
+	case "info", "":
+		return Info, nil
+	case "warn", "warning":
+		return Warn, nil
+	case "error":
+		return Error, nil
+	}
+	// 영어 메시지를 쓰는 s3-uploader와 함께 쓰므로 오류 메시지는 영어
+	return Info, fmt.Errorf("log-level must be one of %s: %s", Names, name)
+}
+
+// Set 이름으로 현재 수준 설정
+func Set(name string) error {
+	level, err := Parse(name)
+	if err != nil {
+		return err
+	}
+	current.Store(int32(level))
+	return nil
+}
+
+// SetOutput 출력 함수 교체 (기본: log.Printf). 프로그램 시작 시 한 번만 호출
+func SetOutput(fn func(format string, args ...any)) {
+	printf = fn
+}
+
+// Enabled level이 현재 수준 이상이면 true
+func Enabled(level Level) bool {
+	return level >= Level(current.Load())
+}
+
+func logf(level Level, format string, args ...any) {
+	if Enabled(level) {
+		printf(format, args...)
+	}
+}
+
+// Debugf 디버깅용 상세 로그
+func Debugf(format string, args ...any) { logf(Debug, format, args...) }
+
+// Infof 파일/항목별 진행 로그 (기본 수준)
+func Infof(format string, args ...any) { logf(Info, format, args...) }
+
+// Warnf 처리를 계속하는 실패/경고
+func Warnf(format string, args ...any) { logf(Warn, format, args...) }
+
+// Errorf 처리를 중단하지 않는 오류
+func Errorf(format string, args ...any) { logf(Error, format, args...) }
@@ -2,8 +2,13 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -11,6 +16,10 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/unboxerscorp/utility/common/loglevel"
+	"github.com/unboxerscorp/utility/inbrain-exercise-uploader/internal/safefile"
 )
 
 type ExerciseGroup struct {
@@ -35,63 +44,191 @@ type CrossingResult struct {
 	CrossingGroups  []CrossingGroup
 	Representative  int
 	SelectionReason string
+	// -min-group-size보다 작은 그룹 (-small-groups=flag일 때만 표시)
+	BelowMinGroupSize bool `json:",omitempty"`
+}
+
+// RunMetadata 결과 파일이 어떤 입력/설정으로 만들어졌는지 기록 (-legacy-output이 아니면 결과 앞에 기록)
+// -legacy-output이면 -resume 확인용으로 작업하는 동안 <output>.partial.meta에 기록
+// 파일로 쓸 때는 끝나면 생성 시각까지 포함해 <output>.meta에 따로 남김
+// csv_uploader는 이 값으로 적용하려는 결과 파일이 맞는지 확인함
+type RunMetadata struct {
+	InputCSV               string
+	InputCSVSHA256         string
+	InputJSON              string
+	InputJSONSHA256        string
+	ExistingGroups         int
+	MaxGroupID             int
+	LoadWorkers            int
+	ProcessWorkers         int
+	RepresentativeStrategy string
+	MinGroupSize           int
+	SmallGroups            string
+	MergeSingletons        bool
+	// 같은 입력으로 만든 결과 파일을 diff로 비교할 수 있도록 결과 파일에는 쓰지 않고 <output>.meta에만 기록
+	CreatedAt string `json:",omitempty"`
 }
 
+// representativeStrategy csv_processor의 대표 문제 선정 방식 (csv_uploader -representative-strategy의 default와 같음)
+const representativeStrategy = "default"
+
+// processWorkers 새 그룹을 처리하는 워커 수
+const processWorkers = 8
+
 type CrossingGroup struct {
 	ID           int
 	Intersection []int
 }
 
+// progress 진행 상황 출력 대상. 결과를 stdout으로 쓸 때는 stderr로 바꿔 결과와 섞이지 않게 함
+var progress io.Writer = os.Stdout
+
 func main() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: go run csv_processor.go <exercise_groups.csv> <pair_groups.json>")
+		fmt.Println("Usage: go run csv_processor.go <exercise_groups.csv> <pair_groups.json> [output.json] [-allow-root=dir] [-checkpoint-every=1000] [-resume] [-load-workers=1] [-min-group-size=0] [-small-groups=flag|drop] [-merge-singletons-into-crossing] [-legacy-output] [-log-level=info]")
+		fmt.Println("       output.json 기본값: csv_results.json, '-'이면 stdout으로 출력 (예: ... - | csv_uploader -)")
 		os.Exit(1)
 	}
 
 	csvFile := os.Args[1]
 	jsonFile := os.Args[2]
+	outputFile := "csv_results.json"
+	flagArgs := os.Args[3:]
+	if len(flagArgs) > 0 && (flagArgs[0] == "-" || !strings.HasPrefix(flagArgs[0], "-")) {
+		outputFile = flagArgs[0]
+		flagArgs = flagArgs[1:]
+	}
+
+	// 플래그 파싱 (위치 인자 뒤에 오는 인자들)
+	var allowRoot, outputRoot, logLevel, smallGroups string
+	var checkpointEvery, loadWorkers, minGroupSize int
+	var resume, mergeSingletons, legacyOutput bool
+	fs := flag.NewFlagSet("csv_processor", flag.ExitOnError)
+	fs.StringVar(&allowRoot, "allow-root", "", "입력 파일을 읽을 수 있는 디렉토리 (비어있으면 제한 없음)")
+	fs.StringVar(&outputRoot, "output-root", "", "출력 파일을 쓸 수 있는 디렉토리 (비어있으면 제한 없음)")
+	fs.IntVar(&checkpointEvery, "checkpoint-every", 1000, "결과 N개마다 <output>.partial에 기록 (0이면 끝날 때만)")
+	fs.BoolVar(&resume, "resume", false, "<output>.partial에 이미 기록된 그룹은 건너뛰고 이어서 처리")
+	fs.IntVar(&loadWorkers, "load-workers", 1, "exercise_groups.csv 행을 파싱할 워커 수 (1이면 순차)")
+	fs.IntVar(&minGroupSize, "min-group-size", 0, "문제 수가 N개 미만인 새 그룹을 -small-groups에 따라 처리 (0이면 사용 안 함)")
+	fs.StringVar(&smallGroups, "small-groups", "flag", "-min-group-size 미만 그룹 처리 (flag: 결과에 BelowMinGroupSize 표시, drop: 결과에서 제외)")
+	fs.BoolVar(&mergeSingletons, "merge-singletons-into-crossing", false, "문제 1개짜리 새 그룹의 문제가 기존 그룹 하나에만 속하고 다른 새 그룹이 그 기존 그룹과 겹치지 않으면 결과에서 빼고 기존 그룹에 그대로 둠")
+	fs.BoolVar(&legacyOutput, "legacy-output", false, "실행 정보(Metadata) 없이 결과 배열만 출력 (이전 형식)")
+	fs.StringVar(&logLevel, "log-level", "info", "진행 로그 수준 ("+loglevel.Names+")")
+	_ = fs.Parse(flagArgs)
+
+	if err := loglevel.Set(logLevel); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
 
-	fmt.Println("Loading exercise groups from CSV...")
-	groups, err := loadExerciseGroups(csvFile)
+	if smallGroups != "flag" && smallGroups != "drop" {
+		fmt.Printf("Error: -small-groups는 flag 또는 drop이어야 합니다: %q\n", smallGroups)
+		os.Exit(1)
+	}
+	if minGroupSize < 0 {
+		fmt.Println("Error: -min-group-size는 0 이상이어야 합니다")
+		os.Exit(1)
+	}
+
+	if outputFile == "-" && resume {
+		fmt.Println("Error: -resume은 파일로 출력할 때만 사용할 수 있습니다")
+		os.Exit(1)
+	}
+
+	if outputFile == "-" {
+		progress = os.Stderr
+	}
+	loglevel.SetOutput(func(format string, args ...any) {
+		fmt.Fprintf(progress, format, args...)
+	})
+
+	loglevel.Infof("Loading exercise groups from CSV...\n")
+	groups, err := loadExerciseGroups(csvFile, allowRoot, loadWorkers)
 	if err != nil {
-		fmt.Printf("Error loading CSV: %v\n", err)
+		fmt.Fprintf(progress, "Error loading CSV: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Loaded %d exercise groups\n", len(groups))
+	loglevel.Infof("Loaded %d exercise groups\n", len(groups))
 
-	fmt.Println("Building problem-to-groups index...")
+	loglevel.Infof("Building problem-to-groups index...\n")
 	problemIndex := buildProblemIndex(groups)
-	fmt.Printf("Indexed %d problems\n", len(problemIndex))
+	loglevel.Infof("Indexed %d problems\n", len(problemIndex))
 
-	fmt.Println("Loading new groups from JSON...")
-	newGroups, err := loadNewGroups(jsonFile)
+	loglevel.Infof("Loading new groups from JSON...\n")
+	newGroups, err := loadNewGroups(jsonFile, allowRoot)
 	if err != nil {
-		fmt.Printf("Error loading JSON: %v\n", err)
+		fmt.Fprintf(progress, "Error loading JSON: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Loaded %d new groups\n", len(newGroups))
+	loglevel.Infof("Loaded %d new groups\n", len(newGroups))
 
-	fmt.Println("Processing groups...")
-	results := processGroups(newGroups, problemIndex, groups)
+	// 그룹 ID 할당 전에 적용해야 -resume으로 이어서 처리해도 같은 ID가 나옴
+	flagBelow := applySmallGroupOptions(newGroups, problemIndex, groups, mergeSingletons, minGroupSize, smallGroups)
 
-	fmt.Println("Writing results...")
-	err = writeResults(results, "csv_results.json")
+	// -legacy-output이어도 -resume에서 체크포인트를 확인할 수 있도록 항상 만듦
+	metadata := &RunMetadata{
+		InputCSV:               csvFile,
+		InputJSON:              jsonFile,
+		ExistingGroups:         len(groups),
+		MaxGroupID:             getMaxGroupID(groups),
+		LoadWorkers:            loadWorkers,
+		ProcessWorkers:         processWorkers,
+		RepresentativeStrategy: representativeStrategy,
+		MinGroupSize:           minGroupSize,
+		SmallGroups:            smallGroups,
+		MergeSingletons:        mergeSingletons,
+		CreatedAt:              time.Now().UTC().Format(time.RFC3339),
+	}
+	if metadata.InputCSVSHA256, err = fileSHA256(csvFile, allowRoot); err == nil {
+		metadata.InputJSONSHA256, err = fileSHA256(jsonFile, allowRoot)
+	}
 	if err != nil {
-		fmt.Printf("Error writing results: %v\n", err)
+		fmt.Fprintf(progress, "Error hashing input: %v\n", err)
 		os.Exit(1)
 	}
 
-	fmt.Printf("Completed! Processed %d groups with %d crossings\n",
-		len(newGroups), countCrossings(results))
+	loglevel.Infof("Processing groups and writing results...\n")
+	writer, err := newResultWriter(outputFile, outputRoot, checkpointEvery, resume, metadata, legacyOutput)
+	if err != nil {
+		fmt.Fprintf(progress, "Error writing results: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 이전 실행의 체크포인트를 새 체크포인트로 옮기고, 그만큼 건너뜀
+	skip := 0
+	if resume {
+		skip, err = writer.resumeFrom(getMaxGroupID(groups) + 1)
+		if err != nil {
+			fmt.Fprintf(progress, "Error resuming from checkpoint: %v\n", err)
+			os.Exit(1)
+		}
+		loglevel.Infof("Resuming after %d already computed groups\n", skip)
+	}
+
+	err = processGroups(newGroups, problemIndex, groups, skip, flagBelow, writer)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		fmt.Fprintf(progress, "Error writing results: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(progress, "Completed! Processed %d groups with %d crossings\n",
+		len(newGroups), writer.crossings)
 }
 
-func loadExerciseGroups(filename string) (map[int]ExerciseGroup, error) {
-	file, err := os.Open(filename)
+func loadExerciseGroups(filename, allowRoot string, workers int) (map[int]ExerciseGroup, error) {
+	file, err := safefile.Open(filename, allowRoot)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
+	if workers > 1 {
+		return loadExerciseGroupsParallel(file, workers)
+	}
+
 	reader := csv.NewReader(file)
 	groups := make(map[int]ExerciseGroup)
 
@@ -110,60 +247,193 @@ func loadExerciseGroups(filename string) (map[int]ExerciseGroup, error) {
 			return nil, err
 		}
 
-		groupID, err := strconv.Atoi(record[0])
-		if err != nil {
-			continue
+		if group, ok := parseGroupRecord(record); ok {
+			groups[group.ID] = group
 		}
+	}
+
+	return groups, nil
+}
+
+// loadChunkBytes 병렬 로딩 시 워커 하나에 넘기는 CSV 청크 크기
+const loadChunkBytes = 4 << 20
+
+type csvChunk struct {
+	index int
+	data  []byte
+}
+
+type chunkResult struct {
+	groups []ExerciseGroup // 청크 안의 행 순서 그대로
+	err    error
+}
+
+// loadExerciseGroupsParallel 파일을 행 경계(따옴표 밖의 줄바꿈)에서 청크로 나눠 워커들이 각각 CSV 파싱
+// 같은 그룹 ID가 여러 행에 있으면 순차 로딩처럼 뒤의 행이 이기도록 청크 순서대로 합침
+func loadExerciseGroupsParallel(file io.Reader, workers int) (map[int]ExerciseGroup, error) {
+	reader := bufio.NewReaderSize(file, 1<<20)
+
+	// 헤더의 필드 수를 모든 청크에 적용 (순차 로딩의 csv.Reader와 같은 검사)
+	header, err := readCSVRecordBytes(reader)
+	if err != nil {
+		return nil, err
+	}
+	headerRecord, err := csv.NewReader(bytes.NewReader(header)).Read()
+	if err != nil {
+		return nil, err
+	}
+	fieldsPerRecord := len(headerRecord)
+
+	chunks := make(chan csvChunk, workers)
+	var mu sync.Mutex
+	results := make(map[int]chunkResult)
 
-		var problemIDs []int
-		if record[1] != "" {
-			problemStrs := strings.Split(record[1], ",")
-			for _, problemStr := range problemStrs {
-				problemID, err := strconv.Atoi(strings.TrimSpace(problemStr))
-				if err == nil {
-					problemIDs = append(problemIDs, problemID)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				var result chunkResult
+				chunkReader := csv.NewReader(bytes.NewReader(chunk.data))
+				chunkReader.FieldsPerRecord = fieldsPerRecord
+				for {
+					record, err := chunkReader.Read()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						result.err = fmt.Errorf("chunk %d: %w", chunk.index, err)
+						break
+					}
+					if group, ok := parseGroupRecord(record); ok {
+						result.groups = append(result.groups, group)
+					}
 				}
+				mu.Lock()
+				results[chunk.index] = result
+				mu.Unlock()
 			}
+		}()
+	}
+
+	// 청크 나누기 - loadChunkBytes를 넘으면 다음 행 경계에서 자름
+	var readErr error
+	var buf []byte
+	index := 0
+	for {
+		record, err := readCSVRecordBytes(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
 		}
+		buf = append(buf, record...)
+		if len(buf) >= loadChunkBytes {
+			chunks <- csvChunk{index: index, data: buf}
+			index++
+			buf = nil
+		}
+	}
+	if len(buf) > 0 {
+		chunks <- csvChunk{index: index, data: buf}
+	}
+	close(chunks)
+	wg.Wait()
+	if readErr != nil {
+		return nil, readErr
+	}
 
-		var problemVideos []bool
-		if len(record) > 2 && record[2] != "" {
-			videoStrs := strings.Split(record[2], ",")
-			for _, videoStr := range videoStrs {
-				problemVideos = append(problemVideos, strings.TrimSpace(videoStr) == "true")
-			}
+	groups := make(map[int]ExerciseGroup)
+	for i := 0; i < len(results); i++ {
+		if results[i].err != nil {
+			return nil, results[i].err
+		}
+		for _, group := range results[i].groups {
+			groups[group.ID] = group
 		}
+	}
+	return groups, nil
+}
 
-		var representative int
-		var hasRepresentative bool
-		var representativeHasVideo bool
-		
-		// representative_problem_id (record[3])
-		if len(record) > 3 && record[3] != "" {
-			representative, _ = strconv.Atoi(record[3])
+// readCSVRecordBytes CSV 한 행의 원본 바이트를 읽음
+// 따옴표 개수의 홀짝으로 따옴표 안인지 추적해 필드 안의 줄바꿈에서는 끊지 않음
+func readCSVRecordBytes(reader *bufio.Reader) ([]byte, error) {
+	var record []byte
+	inQuotes := false
+	for {
+		line, err := reader.ReadBytes('\n')
+		record = append(record, line...)
+		if bytes.Count(line, []byte{'"'})%2 == 1 {
+			inQuotes = !inQuotes
+		}
+		if err == io.EOF && len(record) > 0 {
+			return record, nil
 		}
-		
-		// has_representative (record[4])
-		if len(record) > 4 {
-			hasRepresentative = record[4] == "true"
+		if err != nil {
+			return nil, err
 		}
-		
-		// representative_has_video (record[5])
-		if len(record) > 5 {
-			representativeHasVideo = record[5] == "true"
+		if !inQuotes {
+			return record, nil
 		}
+	}
+}
 
-		groups[groupID] = ExerciseGroup{
-			ID:                    groupID,
-			ProblemIDs:            problemIDs,
-			ProblemVideos:         problemVideos,
-			Representative:        representative,
-			HasRepresentative:     hasRepresentative,
-			RepresentativeHasVideo: representativeHasVideo,
+// parseGroupRecord exercise_groups.csv 한 행을 ExerciseGroup으로 변환 (그룹 ID가 숫자가 아니면 false)
+func parseGroupRecord(record []string) (ExerciseGroup, bool) {
+	groupID, err := strconv.Atoi(record[0])
+	if err != nil {
+		return ExerciseGroup{}, false
+	}
+
+	var problemIDs []int
+	if record[1] != "" {
+		problemStrs := strings.Split(record[1], ",")
+		for _, problemStr := range problemStrs {
+			problemID, err := strconv.Atoi(strings.TrimSpace(problemStr))
+			if err == nil {
+				problemIDs = append(problemIDs, problemID)
+			}
 		}
 	}
 
-	return groups, nil
+	var problemVideos []bool
+	if len(record) > 2 && record[2] != "" {
+		videoStrs := strings.Split(record[2], ",")
+		for _, videoStr := range videoStrs {
+			problemVideos = append(problemVideos, strings.TrimSpace(videoStr) == "true")
+		}
+	}
+
+	var representative int
+	var hasRepresentative bool
+	var representativeHasVideo bool
+
+	// representative_problem_id (record[3])
+	if len(record) > 3 && record[3] != "" {
+		representative, _ = strconv.Atoi(record[3])
+	}
+
+	// has_representative (record[4])
+	if len(record) > 4 {
+		hasRepresentative = record[4] == "true"
+	}
+
+	// representative_has_video (record[5])
+	if len(record) > 5 {
+		representativeHasVideo = record[5] == "true"
+	}
+
+	return ExerciseGroup{
+		ID:                     groupID,
+		ProblemIDs:             problemIDs,
+		ProblemVideos:          problemVideos,
+		Representative:         representative,
+		HasRepresentative:      hasRepresentative,
+		RepresentativeHasVideo: representativeHasVideo,
+	}, true
 }
 
 func buildProblemIndex(groups map[int]ExerciseGroup) map[int][]int {
@@ -178,8 +448,8 @@ func buildProblemIndex(groups map[int]ExerciseGroup) map[int][]int {
 	return index
 }
 
-func loadNewGroups(filename string) ([][]int, error) {
-	file, err := os.Open(filename)
+func loadNewGroups(filename, allowRoot string) ([][]int, error) {
+	file, err := safefile.Open(filename, allowRoot)
 	if err != nil {
 		return nil, err
 	}
@@ -195,28 +465,126 @@ func loadNewGroups(filename string) ([][]int, error) {
 	return newGroups, nil
 }
 
-func processGroups(newGroups [][]int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup) []CrossingResult {
-	results := make([]CrossingResult, 0, len(newGroups))
-	nextGroupID := getMaxGroupID(existingGroups) + 1
+// reorderWindow 순서를 맞추기 위해 결과를 쓰기 전에 보관할 수 있는 최대 그룹 수
+const reorderWindow = 10000
+
+// applySmallGroupOptions -merge-singletons-into-crossing, -min-group-size, -small-groups를 새 그룹에 적용 (제자리 수정)
+// 결과에 BelowMinGroupSize를 표시할 기준 크기를 반환 (표시하지 않으면 0)
+func applySmallGroupOptions(newGroups [][]int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup, mergeSingletons bool, minGroupSize int, smallGroups string) int {
+	if mergeSingletons {
+		merged := mergeSingletonsIntoCrossing(newGroups, problemIndex, existingGroups)
+		loglevel.Infof("Merged %d singleton groups into their crossing group\n", merged)
+	}
+	if minGroupSize <= 0 {
+		return 0
+	}
+	if smallGroups == "drop" {
+		dropped := dropSmallGroups(newGroups, minGroupSize)
+		loglevel.Infof("Dropped %d groups smaller than %d\n", dropped, minGroupSize)
+		return 0
+	}
+	return minGroupSize
+}
+
+// mergeSingletonsIntoCrossing 문제 1개짜리 새 그룹의 문제를 그 문제가 속한 기존 그룹에 그대로 붙여 둠 (제자리 수정, 붙인 수 반환).
+// 새 그룹을 비우면 새 그룹 ID를 받지 않으므로, 업로더가 기존 그룹을 삭제하고 문제 하나만 새 그룹으로 옮기는 일이 없음.
+// 다음 경우에는 어느 쪽에 붙일지 모호하므로 그대로 둠:
+//   - 그 문제가 기존 그룹에 없거나 둘 이상에 속함
+//   - 다른 새 그룹도 그 기존 그룹과 겹침 (업로드하면 기존 그룹이 삭제되어 붙일 곳이 사라짐)
+func mergeSingletonsIntoCrossing(newGroups [][]int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup) int {
+	// 기존 그룹 ID -> 겹치는 새 그룹 수
+	overlaps := make(map[int]int)
+	for _, newGroup := range newGroups {
+		touched := make(map[int]bool)
+		for _, problemID := range newGroup {
+			for _, groupID := range problemIndex[problemID] {
+				touched[groupID] = true
+			}
+		}
+		for groupID := range touched {
+			overlaps[groupID]++
+		}
+	}
+
+	merged := 0
+	for i, newGroup := range newGroups {
+		if len(newGroup) != 1 {
+			continue
+		}
+		groupIDs := problemIndex[newGroup[0]]
+		if len(groupIDs) != 1 || overlaps[groupIDs[0]] != 1 {
+			continue
+		}
+		if _, exists := existingGroups[groupIDs[0]]; !exists {
+			continue
+		}
+		newGroups[i] = nil
+		merged++
+	}
+	return merged
+}
+
+// dropSmallGroups 문제 수가 minSize 미만인 새 그룹을 비움 (제자리 수정, 비운 수 반환).
+// 빈 그룹은 processGroups에서 새 그룹 ID를 받지 않으므로 결과에서 제외됨
+func dropSmallGroups(newGroups [][]int, minSize int) int {
+	dropped := 0
+	for i, newGroup := range newGroups {
+		if len(newGroup) > 0 && len(newGroup) < minSize {
+			newGroups[i] = nil
+			dropped++
+		}
+	}
+	return dropped
+}
+
+type groupJob struct {
+	index      int
+	newGroupID int
+}
+
+// processGroups 새 그룹들을 병렬로 처리하고 NewGroupID 순서대로 writer에 바로 씀
+// 아직 쓰지 않은 결과는 reorderWindow개까지만 보관하므로 입력 크기와 무관하게 메모리가 제한됨
+// skip은 이미 계산된(체크포인트에 있는) 앞쪽 그룹 수 (빈 그룹 제외)
+// flagBelow > 0이면 문제 수가 그보다 적은 결과에 BelowMinGroupSize를 표시함
+func processGroups(newGroups [][]int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup, skip, flagBelow int, writer *resultWriter) error {
+	firstGroupID := getMaxGroupID(existingGroups) + 1 + skip
 
 	// 병렬 처리를 위한 채널과 워커 풀
-	const numWorkers = 8
-	jobs := make(chan int, len(newGroups))
-	resultsChan := make(chan CrossingResult, len(newGroups))
+	jobs := make(chan groupJob, processWorkers)
+	resultsChan := make(chan CrossingResult, processWorkers)
+	window := make(chan struct{}, reorderWindow)
+	stop := make(chan struct{}) // 쓰기에 실패하면 닫아 작업 전송을 멈춤
 
 	var wg sync.WaitGroup
 
 	// 워커 시작
-	for i := 0; i < numWorkers; i++ {
+	for i := 0; i < processWorkers; i++ {
 		wg.Add(1)
-		go worker(jobs, resultsChan, &wg, newGroups, problemIndex, existingGroups, &nextGroupID)
+		go worker(jobs, resultsChan, &wg, newGroups, problemIndex, existingGroups)
 	}
 
-	// 작업 전송
-	for i := range newGroups {
-		jobs <- i
-	}
-	close(jobs)
+	// 작업 전송 (빈 그룹을 제외하고 입력 순서대로 새 그룹 ID를 미리 할당)
+	go func() {
+		defer close(jobs)
+		nextGroupID := firstGroupID
+		skipped := 0
+		for i, newGroup := range newGroups {
+			if len(newGroup) == 0 {
+				continue
+			}
+			if skipped < skip {
+				skipped++
+				continue
+			}
+			select {
+			case window <- struct{}{}:
+			case <-stop:
+				return
+			}
+			jobs <- groupJob{index: i, newGroupID: nextGroupID}
+			nextGroupID++
+		}
+	}()
 
 	// 워커 완료 대기
 	go func() {
@@ -224,39 +592,52 @@ func processGroups(newGroups [][]int, problemIndex map[int][]int, existingGroups
 		close(resultsChan)
 	}()
 
-	// 결과 수집
+	// 결과 수집 - NewGroupID 순서가 될 때까지 보관했다가 순서대로 씀
+	pending := make(map[int]CrossingResult)
+	nextToWrite := firstGroupID
+	var writeErr error
 	for result := range resultsChan {
-		results = append(results, result)
+		if writeErr != nil {
+			continue // 워커가 끝날 수 있도록 이미 전송된 작업의 결과는 받아서 버림
+		}
+		pending[result.NewGroupID] = result
+		for {
+			next, ok := pending[nextToWrite]
+			if !ok {
+				break
+			}
+			delete(pending, nextToWrite)
+			nextToWrite++
+			<-window
+			if flagBelow > 0 && len(next.ProblemIDs) < flagBelow {
+				next.BelowMinGroupSize = true
+			}
+			if err := writer.Write(next); err != nil {
+				writeErr = err
+				close(stop)
+				break
+			}
+		}
 	}
 
-	// 결과 정렬
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].NewGroupID < results[j].NewGroupID
-	})
-
-	return results
+	return writeErr
 }
 
-func worker(jobs <-chan int, results chan<- CrossingResult, wg *sync.WaitGroup,
-	newGroups [][]int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup, nextGroupID *int) {
+func worker(jobs <-chan groupJob, results chan<- CrossingResult, wg *sync.WaitGroup,
+	newGroups [][]int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup) {
 	defer wg.Done()
 
-	for i := range jobs {
-		if i%1000 == 0 {
-			fmt.Printf("Processing group %d/%d...\n", i+1, len(newGroups))
+	for job := range jobs {
+		if job.index%1000 == 0 {
+			loglevel.Infof("Processing group %d/%d...\n", job.index+1, len(newGroups))
 		}
 
-		newGroup := newGroups[i]
-		if len(newGroup) == 0 {
-			continue
-		}
-
-		result := processGroup(newGroup, problemIndex, existingGroups, nextGroupID)
+		result := processGroup(newGroups[job.index], problemIndex, existingGroups, job.newGroupID)
 		results <- result
 	}
 }
 
-func processGroup(newGroup []int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup, nextGroupID *int) CrossingResult {
+func processGroup(newGroup []int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup, newGroupID int) CrossingResult {
 	// 관련된 기존 그룹들 찾기
 	relatedGroupIDs := make(map[int]bool)
 	for _, problemID := range newGroup {
@@ -286,9 +667,11 @@ func processGroup(newGroup []int, problemIndex map[int][]int, existingGroups map
 		}
 	}
 
-	// 새 그룹 ID 할당
-	newGroupID := *nextGroupID
-	*nextGroupID++
+	// map 순회 순서와 무관하게 같은 입력이면 같은 결과가 나오도록 기존 그룹 ID 순으로 정렬
+	// (대표 문제 선정도 이 순서를 따름)
+	sort.Slice(crossingGroups, func(i, j int) bool {
+		return crossingGroups[i].ID < crossingGroups[j].ID
+	})
 
 	// 대표 문제 선정 로직
 	representative, selectionReason := selectBestRepresentative(newGroup, crossingGroups, existingGroups)
@@ -321,6 +704,21 @@ func findIntersection(slice1, slice2 []int) []int {
 	return intersection
 }
 
+// fileSHA256 입력 파일의 SHA-256 (hex)
+func fileSHA256(filename, allowRoot string) (string, error) {
+	file, err := safefile.Open(filename, allowRoot)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 func getMaxGroupID(groups map[int]ExerciseGroup) int {
 	maxID := 0
 	for id := range groups {
@@ -331,29 +729,368 @@ func getMaxGroupID(groups map[int]ExerciseGroup) int {
 	return maxID
 }
 
-func writeResults(results []CrossingResult, filename string) error {
-	file, err := os.Create(filename)
+// resultWriter 결과를 하나씩 JSON 배열로 씀 (전체를 메모리에 모으지 않음)
+// 출력 형식은 결과 슬라이스를 들여쓰기 2칸으로 한 번에 인코딩한 것과 같음
+// 파일로 쓸 때는 <filename>.partial에 쓰다가 Close에서 filename으로 옮김
+type resultWriter struct {
+	file            *os.File
+	writer          *bufio.Writer
+	path            string
+	partialPath     string
+	checkpointEvery int
+	count           int
+	crossings       int
+	metadata        *RunMetadata // nil이면 -resume에서 실행 정보를 확인하지 않음
+	legacy          bool         // 결과 배열만 씀 (-legacy-output). 실행 정보는 metaPath에 따로 기록
+	metaPath        string
+	outputRoot      string
+	indent          string
+}
+
+// newResultWriter 결과 파일을 생성. filename이 "-"이면 stdout으로 씀
+// outputRoot가 비어있지 않으면 그 하위 경로에만 씀
+// checkpointEvery개마다 버퍼를 비워 중단되어도 -resume으로 이어갈 수 있게 함
+// legacy가 아니면 {"Metadata": ..., "Results": [...]} 형식으로, legacy이면 결과 배열만 씀
+// legacy이고 파일로 쓸 때는 metadata를 <filename>.partial.meta에 따로 기록해 -resume에서 확인함
+func newResultWriter(filename, outputRoot string, checkpointEvery int, resume bool, metadata *RunMetadata, legacy bool) (*resultWriter, error) {
+	w := &resultWriter{checkpointEvery: checkpointEvery, metadata: metadata, legacy: legacy, outputRoot: outputRoot, indent: "    "}
+	if legacy {
+		w.indent = "  "
+	}
+	out := os.Stdout
+	if filename != "-" {
+		cleanPath, err := safefile.Clean(filename, outputRoot)
+		if err != nil {
+			return nil, err
+		}
+		filename = cleanPath
+		w.path = filename
+		w.partialPath = filename + ".partial"
+
+		// 이어서 처리할 때는 이전 체크포인트를 resumeFrom에서 읽을 수 있도록 보관
+		// .prev가 이미 있으면 이전 -resume이 옮기기를 끝내지 못한 것이므로 .partial보다 .prev가 완전함 (덮어쓰지 않음)
+		if resume {
+			_, err := os.Stat(w.partialPath + ".prev")
+			if errors.Is(err, os.ErrNotExist) {
+				err = w.keepCheckpoint()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if legacy && metadata != nil {
+			w.metaPath = w.partialPath + ".meta"
+			if err := writeMetadata(w.metaPath, outputRoot, metadata); err != nil {
+				return nil, err
+			}
+		}
+
+		file, err := safefile.Create(w.partialPath, outputRoot)
+		if err != nil {
+			return nil, err
+		}
+		w.file = file
+		out = file
+	}
+	w.writer = bufio.NewWriter(out)
+	return w, nil
+}
+
+// keepCheckpoint <filename>.partial(과 .partial.meta)을 .prev로 옮김 (없으면 아무것도 안 함)
+func (w *resultWriter) keepCheckpoint() error {
+	if err := os.Rename(w.partialPath, w.partialPath+".prev"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(w.partialPath+".meta", w.partialPath+".meta.prev"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// resumeFrom 이전 체크포인트(<filename>.partial.prev)에 끝까지 기록된 결과를 새 체크포인트로 옮기고 그 수를 반환
+// 마지막 결과가 쓰다 만 상태이면 버리고 다시 계산함
+// 이전 체크포인트가 이번 실행과 맞지 않으면 새 체크포인트를 버리고 이전 체크포인트를 되돌려 놓음
+func (w *resultWriter) resumeFrom(firstGroupID int) (int, error) {
+	count, err := w.copyCheckpoint(firstGroupID)
 	if err != nil {
+		if restoreErr := w.restoreCheckpoint(); restoreErr != nil {
+			return 0, fmt.Errorf("%w (restoring checkpoint failed: %v)", err, restoreErr)
+		}
+		return 0, err
+	}
+
+	if err := os.Remove(w.partialPath + ".prev"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	if err := os.Remove(w.partialPath + ".meta.prev"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	return count, nil
+}
+
+// restoreCheckpoint 새로 만든 체크포인트를 닫고 .prev를 <filename>.partial로 되돌림
+// 다음 -resume이 빈 체크포인트가 아닌 이전 결과에서 이어갈 수 있게 함
+func (w *resultWriter) restoreCheckpoint() error {
+	if w.partialPath == "" {
+		return nil
+	}
+	if _, err := os.Stat(w.partialPath + ".prev"); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if w.file != nil {
+		_ = w.file.Close()
+	}
+	if err := os.Rename(w.partialPath+".prev", w.partialPath); err != nil {
 		return err
 	}
+	err := os.Rename(w.partialPath+".meta.prev", w.partialPath+".meta")
+	if errors.Is(err, os.ErrNotExist) {
+		// 실행 정보 없이 남은 체크포인트: 이번 실행이 쓴 실행 정보는 이 체크포인트와 맞지 않으므로 지움
+		err = os.Remove(w.partialPath + ".meta")
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+	}
+	return err
+}
+
+// copyCheckpoint 이전 체크포인트에서 끝까지 기록된 결과를 확인하며 새 체크포인트에 씀
+func (w *resultWriter) copyCheckpoint(firstGroupID int) (int, error) {
+	prevPath := w.partialPath + ".prev"
+	file, err := os.Open(prevPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
 	defer file.Close()
 
-	writer := bufio.NewWriter(file)
-	defer writer.Flush()
+	decoder := json.NewDecoder(file)
+	err = w.skipCheckpointHead(decoder)
+	if errors.Is(err, errCheckpointMismatch) {
+		return 0, err
+	}
+	if err == nil {
+		for decoder.More() {
+			var result CrossingResult
+			if err := decoder.Decode(&result); err != nil {
+				break
+			}
+			// 새 그룹 ID는 입력 순서대로 할당되므로 입력이 같다면 연속이어야 함
+			if result.NewGroupID != firstGroupID+w.count {
+				return 0, fmt.Errorf("checkpoint does not match input: expected NewGroupID %d, got %d", firstGroupID+w.count, result.NewGroupID)
+			}
+			if err := w.Write(result); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := w.writer.Flush(); err != nil {
+		return 0, err
+	}
+	return w.count, nil
+}
+
+// errCheckpointMismatch 이전 체크포인트가 다른 출력 형식이나 다른 입력으로 만들어짐
+var errCheckpointMismatch = errors.New("checkpoint does not match this run")
 
-	encoder := json.NewEncoder(writer)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(results)
+// skipCheckpointHead 이전 체크포인트에서 첫 결과 앞까지 읽음. 쓰다 만 파일이면 에러를 반환해 처음부터 계산함
+// 입력 해시와 결과를 바꾸는 옵션이 이번 실행과 같아야 이어서 씀 (다르면 errCheckpointMismatch)
+// -legacy-output 체크포인트는 <output>.partial.meta.prev의 실행 정보로 확인함
+func (w *resultWriter) skipCheckpointHead(decoder *json.Decoder) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == json.Delim('[') {
+		if !w.legacy {
+			return fmt.Errorf("%w: written with -legacy-output", errCheckpointMismatch)
+		}
+		return w.checkLegacyMetadata()
+	}
+	if w.legacy {
+		return fmt.Errorf("%w: written without -legacy-output", errCheckpointMismatch)
+	}
+
+	var prev RunMetadata
+	for {
+		key, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "Metadata":
+			if err := decoder.Decode(&prev); err != nil {
+				return err
+			}
+			if w.metadata == nil {
+				break
+			}
+			if diff := resultShapeDiff(prev, *w.metadata); diff != "" {
+				return fmt.Errorf("%w: %s", errCheckpointMismatch, diff)
+			}
+		case "Results":
+			_, err := decoder.Token()
+			return err
+		default:
+			return fmt.Errorf("unexpected key in checkpoint: %v", key)
+		}
+	}
 }
 
-func countCrossings(results []CrossingResult) int {
-	count := 0
-	for _, result := range results {
-		if len(result.CrossingGroups) > 0 {
-			count++
+// checkLegacyMetadata -legacy-output 체크포인트와 함께 기록된 실행 정보를 이번 실행과 비교
+// 실행 정보 파일이 없으면 어떤 입력으로 만들었는지 알 수 없으므로 이어서 쓰지 않음
+func (w *resultWriter) checkLegacyMetadata() error {
+	if w.metadata == nil {
+		return nil
+	}
+	data, err := os.ReadFile(w.partialPath + ".meta.prev")
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s.meta not found, delete %s to start over", errCheckpointMismatch, w.partialPath, w.partialPath)
+	}
+	if err != nil {
+		return err
+	}
+	var prev RunMetadata
+	if err := json.Unmarshal(data, &prev); err != nil {
+		return fmt.Errorf("%w: invalid %s.meta: %v", errCheckpointMismatch, w.partialPath, err)
+	}
+	if diff := resultShapeDiff(prev, *w.metadata); diff != "" {
+		return fmt.Errorf("%w: %s", errCheckpointMismatch, diff)
+	}
+	return nil
+}
+
+// resultShapeDiff 두 실행의 결과가 달라질 수 있는 실행 정보 중 처음으로 다른 항목을 설명 (같으면 "")
+// 입력 해시와 결과를 바꾸는 옵션만 비교하고, 경로, 워커 수, 생성 시각은 결과에 영향이 없으므로 비교하지 않음
+func resultShapeDiff(prev, cur RunMetadata) string {
+	switch {
+	case prev.InputCSVSHA256 != cur.InputCSVSHA256 || prev.InputJSONSHA256 != cur.InputJSONSHA256:
+		return "written for different input files"
+	case prev.ExistingGroups != cur.ExistingGroups || prev.MaxGroupID != cur.MaxGroupID:
+		return fmt.Sprintf("written for %d existing groups (max ID %d), now %d (max ID %d)",
+			prev.ExistingGroups, prev.MaxGroupID, cur.ExistingGroups, cur.MaxGroupID)
+	case prev.RepresentativeStrategy != cur.RepresentativeStrategy:
+		return fmt.Sprintf("written with representative strategy %q, now %q", prev.RepresentativeStrategy, cur.RepresentativeStrategy)
+	case prev.MinGroupSize != cur.MinGroupSize:
+		return fmt.Sprintf("written with -min-group-size=%d, now %d", prev.MinGroupSize, cur.MinGroupSize)
+	case prev.SmallGroups != cur.SmallGroups:
+		return fmt.Sprintf("written with -small-groups=%s, now %s", prev.SmallGroups, cur.SmallGroups)
+	case prev.MergeSingletons != cur.MergeSingletons:
+		return fmt.Sprintf("written with -merge-singletons-into-crossing=%t, now %t", prev.MergeSingletons, cur.MergeSingletons)
+	}
+	return ""
+}
+
+// writeMetadata 실행 정보를 JSON 파일로 기록
+func writeMetadata(filename, root string, metadata *RunMetadata) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := safefile.Create(filename, root)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// head 첫 결과 앞에 쓰는 내용 (생성 시각은 빼고 기록)
+func (w *resultWriter) head() (string, error) {
+	if w.legacy {
+		return "[", nil
+	}
+	var metadata *RunMetadata
+	if w.metadata != nil {
+		shape := *w.metadata
+		shape.CreatedAt = ""
+		metadata = &shape
+	}
+	data, err := json.MarshalIndent(metadata, "  ", "  ")
+	if err != nil {
+		return "", err
+	}
+	return "{\n  \"Metadata\": " + string(data) + ",\n  \"Results\": [", nil
+}
+
+func (w *resultWriter) Write(result CrossingResult) error {
+	data, err := json.MarshalIndent(result, w.indent, "  ")
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n" + w.indent
+	if w.count == 0 {
+		head, err := w.head()
+		if err != nil {
+			return err
+		}
+		sep = head + "\n" + w.indent
+	}
+	if _, err := w.writer.WriteString(sep); err != nil {
+		return err
+	}
+	if _, err := w.writer.Write(data); err != nil {
+		return err
+	}
+
+	w.count++
+	if len(result.CrossingGroups) > 0 {
+		w.crossings++
+	}
+
+	// 체크포인트
+	if w.checkpointEvery > 0 && w.count%w.checkpointEvery == 0 {
+		return w.writer.Flush()
+	}
+	return nil
+}
+
+// Close 배열을 닫고 버퍼를 비운 뒤 파일을 닫고, 체크포인트 파일을 결과 파일로 옮김
+func (w *resultWriter) Close() error {
+	tail := "\n]\n"
+	if !w.legacy {
+		tail = "\n  ]\n}\n"
+	}
+	if w.count == 0 {
+		head, err := w.head()
+		if err != nil {
+			return err
+		}
+		tail = head + "]\n"
+		if !w.legacy {
+			tail += "}\n"
+		}
+	}
+	_, err := w.writer.WriteString(tail)
+	if err == nil {
+		err = w.writer.Flush()
+	}
+	if w.file != nil {
+		if closeErr := w.file.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(w.partialPath, w.path)
+		}
+		if err == nil && w.metaPath != "" {
+			err = os.Remove(w.metaPath)
+		}
+		if err == nil && w.metadata != nil {
+			err = writeMetadata(w.path+".meta", w.outputRoot, w.metadata)
 		}
 	}
-	return count
+	return err
 }
 
 // selectBestRepresentative는 교차 그룹을 고려하여 최적의 대표 문제를 선택합니다
@@ -0,0 +1,639 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeGroupsCSV rows개의 행을 가진 exercise_groups.csv를 만듦
+// 문제 ID 필드는 따옴표 안에 쉼표가 들어가므로 청크 경계 처리도 함께 확인됨
+func writeGroupsCSV(tb testing.TB, rows int) string {
+	tb.Helper()
+	var b strings.Builder
+	b.WriteString("group_id,problem_ids,problem_videos,representative_problem_id,has_representative,representative_has_video\n")
+	for i := 1; i <= rows; i++ {
+		fmt.Fprintf(&b, "%d,\"%d,%d,%d\",\"true,false,true\",%d,true,true\n", i, i*3, i*3+1, i*3+2, i*3)
+	}
+	path := filepath.Join(tb.TempDir(), "exercise_groups.csv")
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadExerciseGroupsParallelMatchesSerial(t *testing.T) {
+	path := writeGroupsCSV(t, 5000)
+
+	serial, err := loadExerciseGroups(path, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{2, 8} {
+		parallel, err := loadExerciseGroups(path, "", workers)
+		if err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+		if !reflect.DeepEqual(serial, parallel) {
+			t.Errorf("workers=%d: result differs from serial loader", workers)
+		}
+	}
+}
+
+func BenchmarkLoadCSV(b *testing.B) {
+	path := writeGroupsCSV(b, 300000)
+	info, err := os.Stat(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, workers := range []int{1, 4, 8} {
+		name := "serial"
+		if workers > 1 {
+			name = fmt.Sprintf("parallel-%d", workers)
+		}
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(info.Size())
+			for i := 0; i < b.N; i++ {
+				if _, err := loadExerciseGroups(path, "", workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// runProcessor 새 그룹을 processGroups로 처리해 -legacy-output 형식으로 쓰고 결과를 읽어 옴
+func runProcessor(t *testing.T, newGroups [][]int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup, flagBelow int) []CrossingResult {
+	t.Helper()
+	var results []CrossingResult
+	if err := json.Unmarshal(runProcessorOutput(t, newGroups, problemIndex, existingGroups, flagBelow, nil), &results); err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+// runProcessorOutput processGroups가 쓴 결과 파일 내용. metadata가 nil이면 -legacy-output 형식
+func runProcessorOutput(t *testing.T, newGroups [][]int, problemIndex map[int][]int, existingGroups map[int]ExerciseGroup, flagBelow int, metadata *RunMetadata) []byte {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "csv_results.json")
+	writer, err := newResultWriter(out, "", 0, false, metadata, metadata == nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := processGroups(newGroups, problemIndex, existingGroups, 0, flagBelow, writer); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestProcessGroupsIsReproducible(t *testing.T) {
+	// 기존 그룹 i는 문제 3i, 3i+1, 3i+2이고 대표 문제는 모두 해설 영상이 있어 동률
+	existing := make(map[int]ExerciseGroup)
+	for i := 1; i <= 400; i++ {
+		existing[i] = ExerciseGroup{
+			ID:                     i,
+			ProblemIDs:             []int{3 * i, 3*i + 1, 3*i + 2},
+			ProblemVideos:          []bool{true, true, true},
+			Representative:         3 * i,
+			HasRepresentative:      true,
+			RepresentativeHasVideo: true,
+		}
+	}
+	// 새 그룹마다 기존 그룹 4개와 겹치므로 맵 순회 순서가 결과에 새면 실행마다 달라짐
+	var newGroups [][]int
+	for i := 1; i+3 <= 400; i++ {
+		newGroups = append(newGroups, []int{3 * i, 3*(i+1) + 1, 3*(i+2) + 2, 3 * (i + 3)})
+		if i%50 == 0 {
+			newGroups = append(newGroups, nil) // 빈 그룹은 ID를 받지 않음
+		}
+	}
+	problemIndex := buildProblemIndex(existing)
+
+	first := runProcessorOutput(t, newGroups, problemIndex, existing, 0, nil)
+	for run := 2; run <= 5; run++ {
+		if got := runProcessorOutput(t, newGroups, problemIndex, existing, 0, nil); !bytes.Equal(got, first) {
+			t.Fatalf("run %d output differs from run 1", run)
+		}
+	}
+
+	// 기본 형식도 실행 시각만 다른 두 실행의 결과 파일이 같아야 함
+	metadata := func(createdAt string) *RunMetadata {
+		return &RunMetadata{InputCSV: "groups.csv", InputCSVSHA256: "csv", InputJSON: "new.json", InputJSONSHA256: "json", CreatedAt: createdAt}
+	}
+	withMetadata := runProcessorOutput(t, newGroups, problemIndex, existing, 0, metadata("2026-01-01T00:00:00Z"))
+	if got := runProcessorOutput(t, newGroups, problemIndex, existing, 0, metadata("2026-01-02T09:30:00Z")); !bytes.Equal(got, withMetadata) {
+		t.Fatal("default output differs between runs at different times")
+	}
+
+	var results []CrossingResult
+	if err := json.Unmarshal(first, &results); err != nil {
+		t.Fatal(err)
+	}
+	id := 401
+	for _, group := range newGroups {
+		if len(group) == 0 {
+			continue
+		}
+		result := results[id-401]
+		if result.NewGroupID != id || !reflect.DeepEqual(result.ProblemIDs, group) {
+			t.Fatalf("result %d = (%d, %v), want (%d, %v)", id-401, result.NewGroupID, result.ProblemIDs, id, group)
+		}
+		id++
+	}
+	if len(results) != id-401 {
+		t.Errorf("%d results, want %d", len(results), id-401)
+	}
+}
+
+func TestSmallGroupOptions(t *testing.T) {
+	existing := map[int]ExerciseGroup{
+		10: {ID: 10, ProblemIDs: []int{1, 2, 3}},
+		20: {ID: 20, ProblemIDs: []int{4, 5}},
+		30: {ID: 30, ProblemIDs: []int{7, 8}},
+		40: {ID: 40, ProblemIDs: []int{12}},
+		50: {ID: 50, ProblemIDs: []int{12, 13}},
+	}
+	input := [][]int{
+		{1},        // 기존 그룹 10에만 속하고 다른 새 그룹은 10과 겹치지 않음 -> 병합 대상
+		{4},        // 기존 그룹 20에 속하지만 {5, 6}도 20과 겹침
+		{5, 6},     // 2개
+		{9},        // 기존 그룹 없음
+		{7, 8, 11}, // 3개
+		{12},       // 기존 그룹 둘(40, 50)에 속함
+	}
+
+	tests := []struct {
+		name            string
+		mergeSingletons bool
+		minGroupSize    int
+		smallGroups     string
+		want            [][]int
+		wantFlagged     []bool
+	}{
+		{
+			name:        "default",
+			smallGroups: "flag",
+			want:        [][]int{{1}, {4}, {5, 6}, {9}, {7, 8, 11}, {12}},
+			wantFlagged: []bool{false, false, false, false, false, false},
+		},
+		{
+			name:            "merge singletons",
+			mergeSingletons: true,
+			smallGroups:     "flag",
+			want:            [][]int{{4}, {5, 6}, {9}, {7, 8, 11}, {12}},
+			wantFlagged:     []bool{false, false, false, false, false},
+		},
+		{
+			name:         "flag below 2",
+			minGroupSize: 2,
+			smallGroups:  "flag",
+			want:         [][]int{{1}, {4}, {5, 6}, {9}, {7, 8, 11}, {12}},
+			wantFlagged:  []bool{true, true, false, true, false, true},
+		},
+		{
+			name:         "drop below 3",
+			minGroupSize: 3,
+			smallGroups:  "drop",
+			want:         [][]int{{7, 8, 11}},
+			wantFlagged:  []bool{false},
+		},
+		{
+			name:            "merge then flag below 2",
+			mergeSingletons: true,
+			minGroupSize:    2,
+			smallGroups:     "flag",
+			want:            [][]int{{4}, {5, 6}, {9}, {7, 8, 11}, {12}},
+			wantFlagged:     []bool{true, false, true, false, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newGroups := make([][]int, len(input))
+			for i, g := range input {
+				newGroups[i] = append([]int(nil), g...)
+			}
+			problemIndex := buildProblemIndex(existing)
+
+			flagBelow := applySmallGroupOptions(newGroups, problemIndex, existing, tt.mergeSingletons, tt.minGroupSize, tt.smallGroups)
+			results := runProcessor(t, newGroups, problemIndex, existing, flagBelow)
+
+			if len(results) != len(tt.want) {
+				t.Fatalf("got %d results, want %d: %+v", len(results), len(tt.want), results)
+			}
+			for i, result := range results {
+				// 남은 그룹에만 기존 최대 ID 다음부터 연속으로 ID가 할당됨
+				if result.NewGroupID != 51+i {
+					t.Errorf("result %d: NewGroupID = %d, want %d", i, result.NewGroupID, 51+i)
+				}
+				if !reflect.DeepEqual(result.ProblemIDs, tt.want[i]) {
+					t.Errorf("result %d: ProblemIDs = %v, want %v", i, result.ProblemIDs, tt.want[i])
+				}
+				if result.BelowMinGroupSize != tt.wantFlagged[i] {
+					t.Errorf("result %d %v: BelowMinGroupSize = %v, want %v", i, result.ProblemIDs, result.BelowMinGroupSize, tt.wantFlagged[i])
+				}
+			}
+		})
+	}
+}
+
+// interruptedRun 결과 n개를 체크포인트로 기록한 뒤 Close 없이 멈춘 실행을 흉내 냄
+func interruptedRun(t *testing.T, out string, metadata *RunMetadata, legacy bool, firstGroupID, n int) {
+	t.Helper()
+	writer, err := newResultWriter(out, "", 1, false, metadata, legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := writer.Write(CrossingResult{NewGroupID: firstGroupID + i, ProblemIDs: []int{i}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.file.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResumeChecksResultShapingMetadata(t *testing.T) {
+	base := RunMetadata{
+		InputCSVSHA256:         "csv",
+		InputJSONSHA256:        "json",
+		ExistingGroups:         3,
+		MaxGroupID:             50,
+		LoadWorkers:            1,
+		ProcessWorkers:         processWorkers,
+		RepresentativeStrategy: representativeStrategy,
+		SmallGroups:            "flag",
+	}
+	tests := []struct {
+		name     string
+		change   func(m *RunMetadata)
+		mismatch bool
+	}{
+		{"same", func(m *RunMetadata) {}, false},
+		{"load workers and paths", func(m *RunMetadata) { m.LoadWorkers = 8; m.InputCSV = "other.csv"; m.CreatedAt = "later" }, false},
+		{"csv hash", func(m *RunMetadata) { m.InputCSVSHA256 = "other" }, true},
+		{"json hash", func(m *RunMetadata) { m.InputJSONSHA256 = "other" }, true},
+		{"max group id", func(m *RunMetadata) { m.MaxGroupID = 51 }, true},
+		{"representative strategy", func(m *RunMetadata) { m.RepresentativeStrategy = "references" }, true},
+		{"min group size", func(m *RunMetadata) { m.MinGroupSize = 2 }, true},
+		{"small groups", func(m *RunMetadata) { m.SmallGroups = "drop" }, true},
+		{"merge singletons", func(m *RunMetadata) { m.MergeSingletons = true }, true},
+	}
+	for _, tt := range tests {
+		for _, legacy := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/legacy=%t", tt.name, legacy), func(t *testing.T) {
+				out := filepath.Join(t.TempDir(), "csv_results.json")
+				prev := base
+				interruptedRun(t, out, &prev, legacy, 51, 2)
+
+				cur := base
+				tt.change(&cur)
+				writer, err := newResultWriter(out, "", 1, true, &cur, legacy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer writer.file.Close()
+
+				skip, err := writer.resumeFrom(51)
+				if tt.mismatch {
+					if !errors.Is(err, errCheckpointMismatch) {
+						t.Fatalf("resumeFrom = %d, %v; want errCheckpointMismatch", skip, err)
+					}
+					return
+				}
+				if err != nil || skip != 2 {
+					t.Fatalf("resumeFrom = %d, %v; want 2, nil", skip, err)
+				}
+			})
+		}
+	}
+}
+
+func TestResumeAfterRestartMidRun(t *testing.T) {
+	existing := map[int]ExerciseGroup{
+		1: {ID: 1, ProblemIDs: []int{1, 2}, ProblemVideos: []bool{true, false}, Representative: 1, HasRepresentative: true, RepresentativeHasVideo: true},
+		2: {ID: 2, ProblemIDs: []int{3, 4}},
+	}
+	problemIndex := buildProblemIndex(existing)
+	var newGroups [][]int
+	for i := 0; i < 40; i++ {
+		newGroups = append(newGroups, []int{1 + i%4, 100 + i})
+	}
+	metadata := &RunMetadata{InputCSVSHA256: "csv", InputJSONSHA256: "json", SmallGroups: "flag", CreatedAt: "2026-01-01T00:00:00Z"}
+
+	tests := []struct {
+		name      string
+		legacy    bool
+		completed int // 중단 전에 체크포인트에 끝까지 기록된 결과 수
+	}{
+		{"metadata output", false, 15},
+		{"legacy output", true, 15},
+		{"legacy output, nothing completed", true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			full := filepath.Join(dir, "full.json")
+			writer, err := newResultWriter(full, "", 0, false, metadata, tt.legacy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := processGroups(newGroups, problemIndex, existing, 0, 0, writer); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// 앞쪽 그룹만 처리하고 다음 결과를 쓰다가 멈춘 실행
+			out := filepath.Join(dir, "csv_results.json")
+			writer, err = newResultWriter(out, "", 1, false, metadata, tt.legacy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := processGroups(newGroups[:tt.completed], problemIndex, existing, 0, 0, writer); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := writer.writer.WriteString(",\n  {\n    \"NewGroupID\": 9"); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.writer.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.file.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// 재시작
+			writer, err = newResultWriter(out, "", 1, true, metadata, tt.legacy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			skip, err := writer.resumeFrom(getMaxGroupID(existing) + 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skip != tt.completed {
+				t.Errorf("resumed after %d groups, want %d", skip, tt.completed)
+			}
+			if err := processGroups(newGroups, problemIndex, existing, skip, 0, writer); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := os.ReadFile(full)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("resumed output differs from uninterrupted run:\n%s\nwant\n%s", got, want)
+			}
+			leftovers, _ := filepath.Glob(out + ".partial*")
+			if len(leftovers) != 0 {
+				t.Errorf("checkpoint files left behind: %v", leftovers)
+			}
+		})
+	}
+}
+
+func TestLegacyResumeWithoutMetadataIsRejected(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "csv_results.json")
+	metadata := &RunMetadata{InputCSVSHA256: "csv", InputJSONSHA256: "json"}
+	interruptedRun(t, out, metadata, true, 1, 2)
+	// 실행 정보 없이 체크포인트만 남은 경우 (이전 버전의 -legacy-output 체크포인트)
+	if err := os.Remove(out + ".partial.meta"); err != nil {
+		t.Fatal(err)
+	}
+
+	writer, err := newResultWriter(out, "", 1, true, metadata, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.file.Close()
+	if skip, err := writer.resumeFrom(1); !errors.Is(err, errCheckpointMismatch) {
+		t.Fatalf("resumeFrom = %d, %v; want errCheckpointMismatch", skip, err)
+	}
+}
+
+func TestResumeMismatchKeepsCheckpoint(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		t.Run(fmt.Sprintf("legacy=%t", legacy), func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "csv_results.json")
+			metadata := &RunMetadata{InputCSVSHA256: "csv", InputJSONSHA256: "json"}
+			interruptedRun(t, out, metadata, legacy, 51, 3)
+
+			// 잘못된 입력으로 -resume하면 실패하지만 체크포인트는 그대로 남아야 함
+			wrong := &RunMetadata{InputCSVSHA256: "other", InputJSONSHA256: "json"}
+			writer, err := newResultWriter(out, "", 1, true, wrong, legacy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skip, err := writer.resumeFrom(51); !errors.Is(err, errCheckpointMismatch) {
+				t.Fatalf("resumeFrom with wrong input = %d, %v; want errCheckpointMismatch", skip, err)
+			}
+			if leftovers, _ := filepath.Glob(out + ".partial*.prev"); len(leftovers) != 0 {
+				t.Errorf("checkpoint not restored after mismatch: %v", leftovers)
+			}
+
+			// 올바른 입력으로 다시 -resume하면 이전 결과에서 이어감
+			writer, err = newResultWriter(out, "", 1, true, metadata, legacy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer writer.file.Close()
+			if skip, err := writer.resumeFrom(51); err != nil || skip != 3 {
+				t.Fatalf("resumeFrom after mismatch = %d, %v; want 3, nil", skip, err)
+			}
+		})
+	}
+}
+
+func TestResumeKeepsUnfinishedPrevCheckpoint(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "csv_results.json")
+	metadata := &RunMetadata{InputCSVSHA256: "csv", InputJSONSHA256: "json"}
+	interruptedRun(t, out, metadata, false, 51, 3)
+
+	// 이전 -resume이 .prev를 옮기다 멈춰 .partial에는 일부만 있는 경우
+	if err := os.Rename(out+".partial", out+".partial.prev"); err != nil {
+		t.Fatal(err)
+	}
+	interruptedRun(t, out, metadata, false, 51, 1)
+
+	writer, err := newResultWriter(out, "", 1, true, metadata, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer writer.file.Close()
+	if skip, err := writer.resumeFrom(51); err != nil || skip != 3 {
+		t.Fatalf("resumeFrom = %d, %v; want 3, nil", skip, err)
+	}
+}
+
+// failingWriter 항상 실패하는 출력 (디스크가 가득 찬 경우 등)
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("no space left on device") }
+
+func TestProcessGroupsWriteErrorDoesNotLeak(t *testing.T) {
+	existing := map[int]ExerciseGroup{1: {ID: 1, ProblemIDs: []int{1}}}
+	problemIndex := buildProblemIndex(existing)
+	// 보관 한도보다 많아야 작업 전송이 window에서 막히는 경우까지 확인됨
+	newGroups := make([][]int, reorderWindow*2)
+	for i := range newGroups {
+		newGroups[i] = []int{1, i + 2}
+	}
+
+	for _, failAfter := range []int{0, 1, reorderWindow + 5} {
+		t.Run(fmt.Sprintf("fail after %d", failAfter), func(t *testing.T) {
+			before := runtime.NumGoroutine()
+
+			// failAfter개까지는 버퍼에 쌓이고, 체크포인트에서 버퍼를 비우며 실패
+			writer := &resultWriter{writer: bufio.NewWriterSize(failingWriter{}, 1<<26), checkpointEvery: failAfter + 1, legacy: true, indent: "  "}
+			if err := processGroups(newGroups, problemIndex, existing, 0, 0, writer); err == nil {
+				t.Fatal("processGroups succeeded, want write error")
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			if n := runtime.NumGoroutine(); n > before {
+				t.Errorf("%d goroutines still running after write error, want %d", n, before)
+			}
+		})
+	}
+}
+
+// BenchmarkProcessGroups 결과를 바로 쓰므로 입력이 커져도 최대 힙 사용량이 결과 전체 크기에 비례하지 않아야 함
+func BenchmarkProcessGroups(b *testing.B) {
+	existing := make(map[int]ExerciseGroup)
+	for id := 1; id <= 10000; id++ {
+		existing[id] = ExerciseGroup{ID: id, ProblemIDs: []int{id * 2, id*2 + 1}, ProblemVideos: []bool{true, false}, Representative: id * 2, HasRepresentative: true}
+	}
+	problemIndex := buildProblemIndex(existing)
+
+	for _, size := range []int{50000, 500000} {
+		newGroups := make([][]int, size)
+		for i := range newGroups {
+			newGroups[i] = []int{2 + i%20000, 100000 + i, 100000 + i + 1}
+		}
+		b.Run(fmt.Sprintf("groups-%d", size), func(b *testing.B) {
+			out := filepath.Join(b.TempDir(), "csv_results.json")
+			b.ReportAllocs()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				stopSampling := sampleHeap(&peak)
+				writer, err := newResultWriter(out, "", 1000, false, &RunMetadata{}, false)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := processGroups(newGroups, problemIndex, existing, 0, 0, writer); err != nil {
+					b.Fatal(err)
+				}
+				if err := writer.Close(); err != nil {
+					b.Fatal(err)
+				}
+				stopSampling()
+			}
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-growth-MB")
+		})
+	}
+}
+
+// sampleHeap 반환된 함수를 부를 때까지 힙 사용량을 주기적으로 확인해 시작 시점 대비 최대 증가량을 peak에 기록
+// (입력과 인덱스처럼 이미 메모리에 있는 데이터는 빼고 처리/쓰기에 쓰인 양만 봄)
+func sampleHeap(peak *uint64) func() {
+	runtime.GC()
+	var base runtime.MemStats
+	runtime.ReadMemStats(&base)
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		var stats runtime.MemStats
+		for {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapAlloc > base.HeapAlloc && stats.HeapAlloc-base.HeapAlloc > *peak {
+				*peak = stats.HeapAlloc - base.HeapAlloc
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
+
+func TestCreatedAtIsWrittenToSidecar(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		t.Run(fmt.Sprintf("legacy=%t", legacy), func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "csv_results.json")
+			metadata := &RunMetadata{InputCSVSHA256: "csv", InputJSONSHA256: "json", CreatedAt: "2026-01-01T00:00:00Z"}
+			writer, err := newResultWriter(out, "", 0, false, metadata, legacy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Write(CrossingResult{NewGroupID: 1, ProblemIDs: []int{1}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(data, []byte("CreatedAt")) {
+				t.Errorf("results file contains CreatedAt:\n%s", data)
+			}
+
+			data, err = os.ReadFile(out + ".meta")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sidecar RunMetadata
+			if err := json.Unmarshal(data, &sidecar); err != nil {
+				t.Fatal(err)
+			}
+			if sidecar != *metadata {
+				t.Errorf("%s.meta = %+v, want %+v", out, sidecar, *metadata)
+			}
+			if leftovers, _ := filepath.Glob(out + ".partial*"); len(leftovers) != 0 {
+				t.Errorf("checkpoint files left behind: %v", leftovers)
+			}
+		})
+	}
+}
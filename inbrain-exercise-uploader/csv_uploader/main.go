@@ -1,11 +1,17 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -13,6 +19,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	_ "github.com/lib/pq"
+
+	"github.com/unboxerscorp/utility/common/envflag"
+	"github.com/unboxerscorp/utility/common/loglevel"
+	"github.com/unboxerscorp/utility/inbrain-exercise-uploader/internal/safefile"
 )
 
 type CrossingResult struct {
@@ -24,65 +34,302 @@ type CrossingResult struct {
 	SelectionReason  string        `json:"SelectionReason"`
 }
 
+// RunMetadata csv_processor가 결과 파일 앞에 기록한 실행 정보 (-legacy-output으로 만든 파일에는 없음)
+type RunMetadata struct {
+	InputCSV               string `json:"InputCSV"`
+	InputCSVSHA256         string `json:"InputCSVSHA256"`
+	InputJSON              string `json:"InputJSON"`
+	InputJSONSHA256        string `json:"InputJSONSHA256"`
+	ExistingGroups         int    `json:"ExistingGroups"`
+	MaxGroupID             int    `json:"MaxGroupID"`
+	LoadWorkers            int    `json:"LoadWorkers"`
+	ProcessWorkers         int    `json:"ProcessWorkers"`
+	RepresentativeStrategy string `json:"RepresentativeStrategy"`
+	MinGroupSize           int    `json:"MinGroupSize"`
+	SmallGroups            string `json:"SmallGroups"`
+	MergeSingletons        bool   `json:"MergeSingletons"`
+	CreatedAt              string `json:"CreatedAt"`
+}
+
 type CrossingGroup struct {
 	ID           int   `json:"ID"`
 	Intersection []int `json:"Intersection"`
 }
 
+// 종료 코드 (자동화에서 실패 종류를 구분하기 위함)
+const (
+	exitUsage      = 1 // 인자/설정 오류
+	exitParseError = 2 // 결과 파일을 읽거나 파싱하지 못함
+	exitDBError    = 3 // DB 연결/업로드 실패
+	exitPartial    = 4 // 업로드는 끝났지만 이동되지 않은 문제가 있음 (-strict이면 그 배치에서 중단)
+)
+
+// errProblemsNotMoved -strict에서 새 그룹으로 이동되지 않은 문제가 있어 배치를 실패 처리함 (DB 오류가 아닌 데이터 문제)
+var errProblemsNotMoved = errors.New("problems not moved")
+
+// uploadOptions 업로드 동작 옵션
+type uploadOptions struct {
+	SkipRepresentative bool // 대표 문제 선정/설정을 건너뜀
+	Strict             bool // 이동되지 않은 문제가 있으면 배치 실패
+	OnlyCrossings      bool // 기존 그룹 하나를 그대로 재확인하는 결과는 건너뜀
+
+	// -only-crossings에서 결과 파일 전체 중 둘 이상의 결과가 교차하는 기존 그룹 ID (main에서 채움)
+	SharedGroups map[int]bool
+
+	// 해설 영상이 있는 대표 후보가 여럿일 때 고르는 방식
+	// default: 먼저 찾은 후보, references: metadata.references 항목이 가장 많은 후보 (같으면 먼저 찾은 후보)
+	RepresentativeStrategy string
+}
+
+// uploadReport 실행 종료 시 출력할 집계
+type uploadReport struct {
+	MissingProblems []int // 새 그룹으로 이동되지 않은 문제 ID (존재하지 않거나 삭제됨)
+	NoOpSkipped     int   // -only-crossings로 건너뛴 결과 수
+	RepChanges      []representativeChange
+}
+
+// representativeChange 교차 그룹의 기존 대표 문제와 새 그룹에 설정한 대표 문제
+type representativeChange struct {
+	NewGroupID              int   // 결과 파일의 NewGroupID
+	GroupID                 int64 // DB에 생성된 exercise_group id
+	CrossingGroupIDs        []int
+	PreviousRepresentatives []int // 교차 그룹들의 기존 대표 문제 (mathflatProblemId)
+	NewRepresentative       int
+}
+
+func usage() {
+	fmt.Println("Usage: go run csv_uploader.go <csv_results.json> [-host=localhost] [-port=5433] [-db=postgres] [-env-file=.env] [-allow-root=dir] [-skip-representative] [-strict] [-only-crossings] [-offset=N] [-limit=K] [-rep-changes-out=file.csv] [-representative-strategy=default] [-expect-csv-sha256=hash] [-expect-json-sha256=hash] [-log-level=info]")
+	fmt.Println("       <csv_results.json> 대신 '-'를 주면 stdin에서 읽음 (예: csv_processor ... - | csv_uploader -)")
+	fmt.Println("       go run csv_uploader.go -group-by-reference='[기출] 2024년 11월' [-host=localhost] ... (읽기 전용, 결과 파일 불필요)")
+	fmt.Println("Exit codes: 0 성공, 1 인자/설정 오류, 2 결과 파일 파싱 실패, 3 DB 오류, 4 일부 문제 미이동")
+	os.Exit(exitUsage)
+}
+
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run csv_uploader.go <csv_results.json> [-host=localhost] [-port=5433] [-db=postgres]")
-		os.Exit(1)
+		usage()
 	}
 
-	resultsFile := os.Args[1]
-	
-	// 기본값 설정
-	dbHost := "localhost"
-	dbPort := "5433"
-	dbName := "postgres"
-	
-	// 플래그 파싱
-	for _, arg := range os.Args[2:] {
-		if strings.HasPrefix(arg, "-host=") {
-			dbHost = strings.TrimPrefix(arg, "-host=")
-		} else if strings.HasPrefix(arg, "-port=") {
-			dbPort = strings.TrimPrefix(arg, "-port=")
-		} else if strings.HasPrefix(arg, "-db=") {
-			dbName = strings.TrimPrefix(arg, "-db=")
-		}
+	// 결과 파일 (-group-by-reference만 쓸 때는 생략 가능)
+	resultsFile := ""
+	flagArgs := os.Args[1:]
+	if os.Args[1] == "-" || !strings.HasPrefix(os.Args[1], "-") {
+		resultsFile = os.Args[1]
+		flagArgs = os.Args[2:]
+	}
+
+	// 플래그 파싱 (결과 파일 뒤에 오는 인자들)
+	var dbHost, dbPort, dbName, envFile, allowRoot, repChangesOut, logLevel string
+	var expectCSVSHA256, expectJSONSHA256, groupByReference string
+	var opts uploadOptions
+	var offset, limit int
+	fs := flag.NewFlagSet("csv_uploader", flag.ExitOnError)
+	fs.StringVar(&dbHost, "host", "localhost", "DB 호스트")
+	fs.StringVar(&dbPort, "port", "5433", "DB 포트")
+	fs.StringVar(&dbName, "db", "postgres", "DB 이름")
+	fs.StringVar(&envFile, "env-file", ".env", "설정을 읽을 .env 파일 (없으면 무시)")
+	fs.StringVar(&allowRoot, "allow-root", "", "결과 파일을 읽을 수 있는 디렉토리 (비어있으면 제한 없음)")
+	fs.BoolVar(&opts.SkipRepresentative, "skip-representative", false, "그룹 생성/재매핑만 하고 대표 문제는 설정하지 않음")
+	fs.BoolVar(&opts.OnlyCrossings, "only-crossings", false, "기존 그룹 하나와 문제가 똑같은 결과(다른 결과가 그 그룹과 교차하지 않을 때)는 건너뜀")
+	fs.BoolVar(&opts.Strict, "strict", false, "새 그룹으로 이동되지 않은 문제가 있으면 해당 배치를 실패 처리")
+	fs.IntVar(&offset, "offset", 0, "앞에서부터 N개 결과를 건너뜀 (-limit로 나눠 적용할 때 이어서 시작할 위치)")
+	fs.IntVar(&limit, "limit", 0, "최대 K개 결과만 적용하고 멈춤 (0이면 전체)")
+	fs.StringVar(&logLevel, "log-level", "info", "진행 로그 수준 ("+loglevel.Names+"). warn이면 배치 진행 로그를 숨기고 경고만 출력")
+	fs.StringVar(&opts.RepresentativeStrategy, "representative-strategy", "default", "해설 영상이 있는 대표 후보가 여럿일 때 선택 방식 (default: 먼저 찾은 후보, references: references가 가장 많은 후보)")
+	fs.StringVar(&expectCSVSHA256, "expect-csv-sha256", "", "결과 파일의 Metadata.InputCSVSHA256이 이 값이 아니면 적용하지 않음")
+	fs.StringVar(&expectJSONSHA256, "expect-json-sha256", "", "결과 파일의 Metadata.InputJSONSHA256이 이 값이 아니면 적용하지 않음")
+	fs.StringVar(&groupByReference, "group-by-reference", "", "references에 이 문자열이 포함된(부분 일치) 문제를 reference별로 카테고리 경로와 함께 출력하고 종료 (읽기 전용)")
+	fs.StringVar(&repChangesOut, "rep-changes-out", "", "대표 문제가 바뀐 그룹 목록을 기록할 CSV 파일 (비어있으면 기록 안 함)")
+	_ = fs.Parse(flagArgs)
+	if resultsFile == "" && groupByReference == "" {
+		usage()
+	}
+
+	// 명시하지 않은 DB 플래그만 환경변수(DB_HOST, DB_PORT, DB_NAME) > .env 파일 순으로 채움
+	// (-strict, -limit 같은 동작 플래그는 셸에 남은 변수로 바뀌지 않도록 바인딩하지 않음)
+	err := envflag.Bind(fs, envFile, map[string]string{
+		"host": "DB_HOST",
+		"port": "DB_PORT",
+		"db":   "DB_NAME",
+	})
+	if err != nil {
+		fmt.Printf("Error loading environment: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := loglevel.Set(logLevel); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	loglevel.SetOutput(func(format string, args ...any) {
+		fmt.Printf(format, args...)
+	})
+	if opts.RepresentativeStrategy != "default" && opts.RepresentativeStrategy != "references" {
+		fmt.Printf("Error: -representative-strategy must be default or references: %s\n", opts.RepresentativeStrategy)
+		os.Exit(exitUsage)
 	}
 
-	fmt.Printf("Connecting to database: host=%s port=%s dbname=%s\n", dbHost, dbPort, dbName)
+	loglevel.Infof("Connecting to database: host=%s port=%s dbname=%s\n", dbHost, dbPort, dbName)
 
 	// DB 연결
 	database, err := connectDB(dbHost, dbPort, dbName)
 	if err != nil {
 		fmt.Printf("Error connecting to database: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitDBError)
 	}
 	defer database.Close()
 
+	if groupByReference != "" {
+		if err := verifySchema(database, referenceReportColumns); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitDBError)
+		}
+		if err := reportByReference(database, groupByReference); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(exitDBError)
+		}
+		return
+	}
+
+	// 업로드 중간에 SQL 에러로 멈추지 않도록 필요한 컬럼이 있는지 먼저 확인
+	if err := verifySchema(database, requiredColumns); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitDBError)
+	}
+
 	// 결과 로드
-	fmt.Println("Loading results from JSON...")
-	results, err := loadResults(resultsFile)
+	loglevel.Infof("Loading results from JSON...\n")
+	results, metadata, err := loadResults(resultsFile, allowRoot)
 	if err != nil {
 		fmt.Printf("Error loading results: %v\n", err)
-		os.Exit(1)
+		os.Exit(exitParseError)
+	}
+	loglevel.Infof("Loaded %d results\n", len(results))
+	if metadata != nil {
+		// 생성 시각은 결과 파일 옆의 <results>.meta에 있음 (예전 결과 파일은 Metadata 안에 있음)
+		createdAt := ""
+		if metadata.CreatedAt != "" {
+			createdAt = " created_at=" + metadata.CreatedAt
+		}
+		loglevel.Infof("Results metadata: csv=%s (sha256 %s) json=%s (sha256 %s) existing_groups=%d max_group_id=%d%s\n",
+			metadata.InputCSV, metadata.InputCSVSHA256, metadata.InputJSON, metadata.InputJSONSHA256,
+			metadata.ExistingGroups, metadata.MaxGroupID, createdAt)
+	}
+	if err := checkMetadata(metadata, expectCSVSHA256, expectJSONSHA256); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitParseError)
+	}
+
+	// 다른 결과가 건드리는 그룹은 -offset/-limit로 나눠 적용해도 알 수 있도록 파일 전체에서 셈
+	if opts.OnlyCrossings {
+		opts.SharedGroups = sharedCrossingGroups(results)
+	}
+
+	// -offset/-limit로 일부만 적용 (스테이징에서 단계적으로 확인할 때)
+	total := len(results)
+	if offset < 0 || limit < 0 {
+		fmt.Println("Error: -offset and -limit must not be negative")
+		os.Exit(exitUsage)
+	}
+	if offset > total {
+		offset = total
+	}
+	results = results[offset:]
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	if offset > 0 || limit > 0 {
+		loglevel.Infof("Applying results %d-%d of %d (-offset=%d -limit=%d)\n", offset, offset+len(results)-1, total, offset, limit)
 	}
-	fmt.Printf("Loaded %d results\n", len(results))
 
 	// DB에 업로드
-	fmt.Println("Uploading to database...")
-	err = uploadResults(database, results)
+	loglevel.Infof("Uploading to database...\n")
+	if opts.SkipRepresentative {
+		loglevel.Infof("Skipping representative selection (-skip-representative)\n")
+	}
+	report := &uploadReport{}
+	err = uploadResults(database, results, opts, report)
+	printReport(report)
+	if repChangesOut != "" {
+		// 업로드가 중간에 실패해도 커밋된 배치의 변경은 기록
+		if writeErr := writeRepChanges(repChangesOut, allowRoot, report.RepChanges); writeErr != nil {
+			fmt.Printf("Error writing representative changes: %v\n", writeErr)
+		} else {
+			fmt.Printf("Wrote %d representative changes to %s\n", len(report.RepChanges), repChangesOut)
+		}
+	}
 	if err != nil {
 		fmt.Printf("Error uploading results: %v\n", err)
-		os.Exit(1)
+		if errors.Is(err, errProblemsNotMoved) {
+			os.Exit(exitPartial)
+		}
+		os.Exit(exitDBError)
+	}
+
+	if remaining := total - offset - len(results); remaining > 0 {
+		fmt.Printf("Applied %d results, %d remaining (continue with -offset=%d)\n", len(results), remaining, offset+len(results))
+	}
+
+	if len(report.MissingProblems) > 0 {
+		fmt.Printf("Upload completed with %d failed problems\n", len(report.MissingProblems))
+		os.Exit(exitPartial)
 	}
 
 	fmt.Println("Upload completed successfully!")
 }
 
+// requiredColumns 업로드에서 읽고 쓰는 테이블과 컬럼
+var requiredColumns = map[string][]string{
+	"exercise_groups": {"id", "category_id", "metadata", "created_at", "updated_at", "deleted_at"},
+	"exercises":       {"id", "category_id", "metadata", "exercise_group_id", "is_representative", "solution_video_id", "updated_at", "deleted_at"},
+}
+
+// referenceReportColumns -group-by-reference에서 읽는 테이블과 컬럼
+var referenceReportColumns = map[string][]string{
+	"exercises":  {"id", "category_id", "metadata", "deleted_at"},
+	"categories": {"id", "name", "parent_id"},
+}
+
+// verifySchema information_schema에서 required의 컬럼이 모두 있는지 확인
+// 없는 컬럼이 있으면 "missing column exercises.is_representative" 형식으로 모두 나열한 에러를 반환
+func verifySchema(db *sql.DB, required map[string][]string) error {
+	rows, err := db.Query(`SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = current_schema()`)
+	if err != nil {
+		return fmt.Errorf("failed to query information_schema: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return fmt.Errorf("failed to scan information_schema: %w", err)
+		}
+		existing[table+"."+column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query information_schema: %w", err)
+	}
+
+	tables := make([]string, 0, len(required))
+	for table := range required {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var missing []string
+	for _, table := range tables {
+		for _, column := range required[table] {
+			if !existing[table+"."+column] {
+				missing = append(missing, "missing column "+table+"."+column)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("database schema check failed: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 func connectDB(host, port, dbName string) (*sql.DB, error) {
 	dbUser := "app_user"
 	
@@ -146,24 +393,76 @@ func getDBPasswordFromSecretsManager(ctx context.Context) (string, error) {
 	return password, nil
 }
 
-func loadResults(filename string) ([]CrossingResult, error) {
-	file, err := os.Open(filename)
+// loadResults 결과 파일을 읽음. filename이 "-"이면 stdin에서 읽음
+// allowRoot가 비어있지 않으면 그 하위 경로만 허용
+func loadResults(filename, allowRoot string) ([]CrossingResult, *RunMetadata, error) {
+	if filename == "-" {
+		return decodeResults(os.Stdin)
+	}
+
+	file, err := safefile.Open(filename, allowRoot)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer file.Close()
 
-	var results []CrossingResult
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&results)
-	if err != nil {
-		return nil, err
+	return decodeResults(file)
+}
+
+// decodeResults {"Metadata": ..., "Results": [...]} 형식과 -legacy-output의 결과 배열 형식을 모두 읽음
+// 결과 배열 형식이면 metadata는 nil
+func decodeResults(r io.Reader) ([]CrossingResult, *RunMetadata, error) {
+	// 첫 JSON 문자로 형식 판단
+	reader := bufio.NewReader(r)
+	var first byte
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if first = b[0]; first != ' ' && first != '\t' && first != '\r' && first != '\n' {
+			break
+		}
+		_, _ = reader.ReadByte()
+	}
+
+	decoder := json.NewDecoder(reader)
+	if first == '[' {
+		var results []CrossingResult
+		if err := decoder.Decode(&results); err != nil {
+			return nil, nil, err
+		}
+		return results, nil, nil
+	}
+
+	var file struct {
+		Metadata *RunMetadata     `json:"Metadata"`
+		Results  []CrossingResult `json:"Results"`
+	}
+	if err := decoder.Decode(&file); err != nil {
+		return nil, nil, err
 	}
+	return file.Results, file.Metadata, nil
+}
 
-	return results, nil
+// checkMetadata 결과 파일이 기대한 입력으로 만들어졌는지 확인 (기대값이 비어있으면 확인하지 않음)
+func checkMetadata(metadata *RunMetadata, expectCSVSHA256, expectJSONSHA256 string) error {
+	if expectCSVSHA256 == "" && expectJSONSHA256 == "" {
+		return nil
+	}
+	if metadata == nil {
+		return fmt.Errorf("results file has no metadata (written with -legacy-output?); cannot check -expect-csv-sha256/-expect-json-sha256")
+	}
+	if expectCSVSHA256 != "" && !strings.EqualFold(metadata.InputCSVSHA256, expectCSVSHA256) {
+		return fmt.Errorf("results were computed from a different CSV: sha256 %s, expected %s", metadata.InputCSVSHA256, expectCSVSHA256)
+	}
+	if expectJSONSHA256 != "" && !strings.EqualFold(metadata.InputJSONSHA256, expectJSONSHA256) {
+		return fmt.Errorf("results were computed from a different JSON: sha256 %s, expected %s", metadata.InputJSONSHA256, expectJSONSHA256)
+	}
+	return nil
 }
 
-func uploadResults(database *sql.DB, results []CrossingResult) error {
+func uploadResults(database *sql.DB, results []CrossingResult, opts uploadOptions, report *uploadReport) error {
 	ctx := context.Background()
 	
 	// 배치 처리를 위한 트랜잭션
@@ -176,39 +475,58 @@ func uploadResults(database *sql.DB, results []CrossingResult) error {
 		}
 		
 		batch := results[i:end]
-		err := processBatch(ctx, database, batch)
+		err := processBatch(ctx, database, batch, opts, report)
 		if err != nil {
 			return fmt.Errorf("failed to process batch %d-%d: %w", i, end-1, err)
 		}
 		
-		fmt.Printf("Processed batch %d-%d (%d/%d)\n", i, end-1, end, len(results))
+		loglevel.Infof("Processed batch %d-%d (%d/%d)\n", i, end-1, end, len(results))
 	}
 	
 	return nil
 }
 
-func processBatch(ctx context.Context, database *sql.DB, batch []CrossingResult) error {
+func processBatch(ctx context.Context, database *sql.DB, batch []CrossingResult, opts uploadOptions, report *uploadReport) error {
 	tx, err := database.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	// 롤백된 배치의 대표 문제 변경은 보고하지 않음
+	repChanges := len(report.RepChanges)
 	for _, result := range batch {
-		err = processResult(ctx, tx, result)
+		err = processResult(ctx, tx, result, opts, report)
 		if err != nil {
+			report.RepChanges = report.RepChanges[:repChanges]
 			return fmt.Errorf("failed to process result %d: %w", result.NewGroupID, err)
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		report.RepChanges = report.RepChanges[:repChanges]
+		return err
+	}
+	return nil
 }
 
-func processResult(ctx context.Context, tx *sql.Tx, result CrossingResult) error {
+func processResult(ctx context.Context, tx *sql.Tx, result CrossingResult, opts uploadOptions, report *uploadReport) error {
 	if len(result.ProblemIDs) == 0 {
 		return nil
 	}
 
+	// 실제 교차 없이 기존 그룹 하나를 재확인하는 결과는 그룹을 다시 만들지 않음
+	if opts.OnlyCrossings {
+		noOp, err := isNoOpResult(ctx, tx, result, opts.SharedGroups)
+		if err != nil {
+			return err
+		}
+		if noOp {
+			report.NoOpSkipped++
+			return nil
+		}
+	}
+
 	// 존재하는 문제의 카테고리 ID 가져오기 (존재하지 않는 문제들은 건너뛰기)
 	var categoryID int64
 	var err error
@@ -224,7 +542,7 @@ func processResult(ctx context.Context, tx *sql.Tx, result CrossingResult) error
 	
 	// 모든 문제가 존재하지 않으면 스킵
 	if categoryID == 0 {
-		fmt.Printf("Warning: Skipping group %d - no valid problems found\n", result.NewGroupID)
+		loglevel.Warnf("Warning: Skipping group %d - no valid problems found\n", result.NewGroupID)
 		return nil
 	}
 
@@ -234,6 +552,15 @@ func processResult(ctx context.Context, tx *sql.Tx, result CrossingResult) error
 		return err
 	}
 
+	// 교차 그룹의 기존 대표 문제는 문제들을 새 그룹으로 옮기기 전에 조회
+	var existingRepresentatives []RepresentativeInfo
+	if !opts.SkipRepresentative {
+		existingRepresentatives, err = getExistingRepresentatives(ctx, tx, result.CrossingGroups)
+		if err != nil {
+			return err
+		}
+	}
+
 	// 기존 교차 그룹들을 deleted로 마킹
 	for _, crossingGroup := range result.CrossingGroups {
 		err = markGroupAsDeleted(ctx, tx, int64(crossingGroup.ID))
@@ -243,24 +570,40 @@ func processResult(ctx context.Context, tx *sql.Tx, result CrossingResult) error
 	}
 
 	// 존재하는 문제들만 새 그룹에 매핑
-	err = updateExercisesGroup(ctx, tx, result.ProblemIDs, newGroupID)
+	missing, err := updateExercisesGroup(ctx, tx, result.ProblemIDs, newGroupID)
 	if err != nil {
 		return err
 	}
+	if len(missing) > 0 {
+		report.MissingProblems = append(report.MissingProblems, missing...)
+		if opts.Strict {
+			return fmt.Errorf("%w to group %d: %v", errProblemsNotMoved, result.NewGroupID, missing)
+		}
+	}
+
+	// 대표 문제는 이후 별도 단계에서 설정
+	if opts.SkipRepresentative {
+		return nil
+	}
 
 	// 올바른 대표 문제 선정 및 설정
-	representative, err := selectBestRepresentative(ctx, tx, result.ProblemIDs, result.CrossingGroups)
+	representative, err := selectBestRepresentative(ctx, tx, result.ProblemIDs, result.CrossingGroups, existingRepresentatives, opts.RepresentativeStrategy)
 	if err != nil {
 		return err
 	}
 	
 	if representative != 0 {
-		err = setRepresentativeExercise(ctx, tx, representative, newGroupID)
+		// 선정한 문제가 그룹에 없으면 다른 문제로 대체되므로 실제로 설정된 문제를 기록
+		representative, err = setRepresentativeExercise(ctx, tx, representative, newGroupID)
 		if err != nil {
 			return err
 		}
 	}
 
+	if change, changed := representativeChanged(result, newGroupID, existingRepresentatives, representative); changed {
+		report.RepChanges = append(report.RepChanges, change)
+	}
+
 	return nil
 }
 
@@ -297,27 +640,96 @@ func markGroupAsDeleted(ctx context.Context, tx *sql.Tx, groupID int64) error {
 	return nil
 }
 
-func updateExercisesGroup(ctx context.Context, tx *sql.Tx, problemIDs []int, newGroupID int64) error {
+// isNoOpResult 결과가 기존 그룹 하나를 그대로 재확인하는지 확인
+// 모든 문제가 같은 기존 그룹 하나에 있고, 그 그룹에 남은 문제 수가 결과의 문제 수와 같으며, shared(다른 결과도 교차하는 그룹)가 아니어야 함
+// 일부만 같은 결과를 건너뛰면 다른 결과가 그 그룹을 삭제할 때 나머지 문제가 삭제된 그룹에 남게 됨
+func isNoOpResult(ctx context.Context, tx *sql.Tx, result CrossingResult, shared map[int]bool) (bool, error) {
+	if len(result.CrossingGroups) != 1 {
+		return false, nil
+	}
+	group := result.CrossingGroups[0]
+	if shared[group.ID] {
+		return false, nil
+	}
+
+	inGroup := make(map[int]bool)
+	for _, problemID := range group.Intersection {
+		inGroup[problemID] = true
+	}
+	problems := make(map[int]bool)
+	for _, problemID := range result.ProblemIDs {
+		if !inGroup[problemID] {
+			return false, nil
+		}
+		problems[problemID] = true
+	}
+
+	query := `SELECT COUNT(*) FROM exercises WHERE exercise_group_id = $1 AND deleted_at IS NULL`
+	var members int
+	if err := tx.QueryRowContext(ctx, query, group.ID).Scan(&members); err != nil {
+		return false, fmt.Errorf("failed to count exercises in group %d: %w", group.ID, err)
+	}
+	return members == len(problems), nil
+}
+
+// sharedCrossingGroups 둘 이상의 결과가 교차하는 기존 그룹 ID
+func sharedCrossingGroups(results []CrossingResult) map[int]bool {
+	seen := make(map[int]bool)
+	shared := make(map[int]bool)
+	for _, result := range results {
+		for _, group := range result.CrossingGroups {
+			if seen[group.ID] {
+				shared[group.ID] = true
+			}
+			seen[group.ID] = true
+		}
+	}
+	return shared
+}
+
+// updateExercisesGroup 문제들을 새 그룹으로 옮기고, 일치하는 행이 없어 이동되지 않은 문제 ID를 반환
+func updateExercisesGroup(ctx context.Context, tx *sql.Tx, problemIDs []int, newGroupID int64) ([]int, error) {
+	var missing []int
 	for _, problemID := range problemIDs {
 		query := `UPDATE exercises SET exercise_group_id = $1, updated_at = NOW()
 				  WHERE metadata->>'mathflatProblemId' = $2 AND deleted_at IS NULL`
 		result, err := tx.ExecContext(ctx, query, newGroupID, strconv.Itoa(problemID))
 		if err != nil {
-			return fmt.Errorf("failed to update exercise %d group: %w", problemID, err)
+			return nil, fmt.Errorf("failed to update exercise %d group: %w", problemID, err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to check exercise %d group update: %w", problemID, err)
+		}
+		if affected == 0 {
+			missing = append(missing, problemID)
 		}
-		// 존재하지 않는 문제는 조용히 무시 (rowsAffected가 0이어도 에러 없음)
-		_ = result
 	}
-	return nil
+	return missing, nil
+}
+
+// printReport 실행 종료 시 집계 출력
+func printReport(report *uploadReport) {
+	if report.NoOpSkipped > 0 {
+		fmt.Printf("Skipped %d no-op results already in a single existing group (-only-crossings)\n", report.NoOpSkipped)
+	}
+	if len(report.MissingProblems) == 0 {
+		return
+	}
+	fmt.Printf("Warning: %d problems were not moved (not found or deleted):\n", len(report.MissingProblems))
+	for _, problemID := range report.MissingProblems {
+		fmt.Printf("  - %d\n", problemID)
+	}
 }
 
-func setRepresentativeExercise(ctx context.Context, tx *sql.Tx, problemID int, groupID int64) error {
+// setRepresentativeExercise 그룹의 대표 문제를 problemID로 설정하고, 실제로 대표가 된 문제 ID를 반환 (없으면 0)
+func setRepresentativeExercise(ctx context.Context, tx *sql.Tx, problemID int, groupID int64) (int, error) {
 	// 먼저 해당 그룹의 모든 is_representative를 false로 설정
 	query := `UPDATE exercises SET is_representative = false, updated_at = NOW()
 			  WHERE exercise_group_id = $1 AND deleted_at IS NULL`
 	_, err := tx.ExecContext(ctx, query, groupID)
 	if err != nil {
-		return fmt.Errorf("failed to clear representative flags: %w", err)
+		return 0, fmt.Errorf("failed to clear representative flags: %w", err)
 	}
 
 	// 선택된 문제를 대표로 설정 (존재하는 경우에만)
@@ -325,67 +737,172 @@ func setRepresentativeExercise(ctx context.Context, tx *sql.Tx, problemID int, g
 			 WHERE metadata->>'mathflatProblemId' = $1 AND exercise_group_id = $2 AND deleted_at IS NULL`
 	result, err := tx.ExecContext(ctx, query, strconv.Itoa(problemID), groupID)
 	if err != nil {
-		return fmt.Errorf("failed to set representative exercise %d: %w", problemID, err)
+		return 0, fmt.Errorf("failed to set representative exercise %d: %w", problemID, err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check representative exercise %d: %w", problemID, err)
+	}
+	if affected > 0 {
+		return problemID, nil
 	}
-	// 존재하지 않는 문제는 조용히 무시
-	_ = result
-
-	return nil
-}
 
-// selectBestRepresentative는 교차 그룹을 고려하여 최적의 대표 문제를 선택합니다
-func selectBestRepresentative(ctx context.Context, tx *sql.Tx, problemIDs []int, crossingGroups []CrossingGroup) (int, error) {
-	if len(problemIDs) == 0 {
+	// 선택된 문제가 그룹에 없으면 (DB에 없는 문제) 그룹의 다른 문제를 대표로 설정
+	// 해설 영상이 있는 문제 우선, 그 다음 가장 최근 문제
+	query = `UPDATE exercises SET is_representative = true, updated_at = NOW()
+			 WHERE id = (SELECT id FROM exercises
+			             WHERE exercise_group_id = $1 AND deleted_at IS NULL
+			             ORDER BY (solution_video_id IS NOT NULL) DESC, id DESC
+			             LIMIT 1)
+			 RETURNING metadata->>'mathflatProblemId'`
+	var fallbackProblemID sql.NullString
+	err = tx.QueryRowContext(ctx, query, groupID).Scan(&fallbackProblemID)
+	if err == sql.ErrNoRows {
+		loglevel.Warnf("Warning: group %d has no exercises, no representative set (chosen problem %d not found)\n", groupID, problemID)
 		return 0, nil
 	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to set fallback representative for group %d: %w", groupID, err)
+	}
+	loglevel.Warnf("Warning: representative problem %d not in group %d, fell back to problem %s\n", problemID, groupID, fallbackProblemID.String)
 
-	// 교차 그룹이 없으면 가장 높은 ID 선택
-	if len(crossingGroups) == 0 {
-		highest := problemIDs[0]
-		for _, id := range problemIDs {
-			if id > highest {
-				highest = id
-			}
-		}
-		return highest, nil
+	// mathflatProblemId가 없거나 숫자가 아닌 문제로 대체되었으면 문제 ID를 알 수 없음 (0)
+	fallback, err := strconv.Atoi(fallbackProblemID.String)
+	if err != nil {
+		return 0, nil
 	}
+	return fallback, nil
+}
 
-	// 교차 그룹들의 기존 대표 문제들 수집
+// getExistingRepresentatives 교차 그룹들의 현재 대표 문제 조회
+func getExistingRepresentatives(ctx context.Context, tx *sql.Tx, crossingGroups []CrossingGroup) ([]RepresentativeInfo, error) {
 	var existingRepresentatives []RepresentativeInfo
 	for _, crossing := range crossingGroups {
 		query := `SELECT id, CAST(metadata->>'mathflatProblemId' AS INTEGER),
 				         CASE WHEN solution_video_id IS NOT NULL THEN true ELSE false END as has_solution_video
 				  FROM exercises
 				  WHERE exercise_group_id = $1 AND is_representative = true AND deleted_at IS NULL`
-		
+
 		rows, err := tx.QueryContext(ctx, query, crossing.ID)
 		if err != nil {
-			return 0, fmt.Errorf("failed to query existing representatives: %w", err)
+			return nil, fmt.Errorf("failed to query existing representatives: %w", err)
 		}
-		
+
 		for rows.Next() {
 			var rep RepresentativeInfo
 			err := rows.Scan(&rep.ExerciseID, &rep.ProblemID, &rep.HasSolutionVideo)
 			if err != nil {
 				rows.Close()
-				return 0, fmt.Errorf("failed to scan representative: %w", err)
+				return nil, fmt.Errorf("failed to scan representative: %w", err)
 			}
 			existingRepresentatives = append(existingRepresentatives, rep)
 		}
 		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to query existing representatives: %w", err)
+		}
+	}
+	return existingRepresentatives, nil
+}
+
+// representativeChanged 기존 대표 문제가 정확히 하나이고 새 대표 문제와 같으면 변경 없음으로 봄
+func representativeChanged(result CrossingResult, groupID int64, existing []RepresentativeInfo, representative int) (representativeChange, bool) {
+	change := representativeChange{
+		NewGroupID:        result.NewGroupID,
+		GroupID:           groupID,
+		NewRepresentative: representative,
+	}
+	for _, crossing := range result.CrossingGroups {
+		change.CrossingGroupIDs = append(change.CrossingGroupIDs, crossing.ID)
+	}
+	for _, rep := range existing {
+		change.PreviousRepresentatives = append(change.PreviousRepresentatives, rep.ProblemID)
+	}
+
+	if len(change.PreviousRepresentatives) == 1 && change.PreviousRepresentatives[0] == representative {
+		return change, false
+	}
+	if len(change.PreviousRepresentatives) == 0 && representative == 0 {
+		return change, false
+	}
+	return change, true
+}
+
+// writeRepChanges 대표 문제 변경 목록을 CSV로 저장
+func writeRepChanges(filename, root string, changes []representativeChange) error {
+	file, err := safefile.Create(filename, root)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	joinIDs := func(ids []int) string {
+		parts := make([]string, 0, len(ids))
+		for _, id := range ids {
+			parts = append(parts, strconv.Itoa(id))
+		}
+		return strings.Join(parts, ";")
 	}
 
+	w := csv.NewWriter(file)
+	if err := w.Write([]string{"new_group_id", "group_id", "crossing_group_ids", "previous_representatives", "new_representative"}); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		record := []string{
+			strconv.Itoa(c.NewGroupID),
+			strconv.FormatInt(c.GroupID, 10),
+			joinIDs(c.CrossingGroupIDs),
+			joinIDs(c.PreviousRepresentatives),
+			strconv.Itoa(c.NewRepresentative),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// selectBestRepresentative는 교차 그룹을 고려하여 최적의 대표 문제를 선택합니다
+func selectBestRepresentative(ctx context.Context, tx *sql.Tx, problemIDs []int, crossingGroups []CrossingGroup, existingRepresentatives []RepresentativeInfo, strategy string) (int, error) {
+	if len(problemIDs) == 0 {
+		return 0, nil
+	}
+
+	// 교차 그룹이 없으면 가장 높은 ID 선택
+	if len(crossingGroups) == 0 {
+		highest := problemIDs[0]
+		for _, id := range problemIDs {
+			if id > highest {
+				highest = id
+			}
+		}
+		return highest, nil
+	}
+
+	// 교차 그룹들의 기존 대표 문제들 (문제 이동 전에 getExistingRepresentatives로 조회)
 	// 기존 대표 문제가 새 그룹에 포함되어 있다면 우선 선택
+	var videoCandidates []int
 	for _, rep := range existingRepresentatives {
 		for _, problemID := range problemIDs {
 			if problemID == rep.ProblemID {
 				// solution_video가 있는 기존 대표 문제를 우선
 				if rep.HasSolutionVideo {
-					return rep.ProblemID, nil
+					if strategy != "references" {
+						return rep.ProblemID, nil
+					}
+					videoCandidates = append(videoCandidates, rep.ProblemID)
 				}
 			}
 		}
 	}
+	if len(videoCandidates) > 0 {
+		return mostReferenced(ctx, tx, videoCandidates)
+	}
 	
 	// solution_video가 없는 기존 대표 문제라도 포함되어 있다면 선택
 	for _, rep := range existingRepresentatives {
@@ -405,9 +922,15 @@ func selectBestRepresentative(ctx context.Context, tx *sql.Tx, problemIDs []int,
 		var hasVideo bool
 		err := tx.QueryRowContext(ctx, query, strconv.Itoa(problemID)).Scan(&hasVideo)
 		if err == nil && hasVideo {
-			return problemID, nil
+			if strategy != "references" {
+				return problemID, nil
+			}
+			videoCandidates = append(videoCandidates, problemID)
 		}
 	}
+	if len(videoCandidates) > 0 {
+		return mostReferenced(ctx, tx, videoCandidates)
+	}
 
 	// solution_video가 없다면 가장 높은 ID 선택
 	highest := problemIDs[0]
@@ -419,9 +942,130 @@ func selectBestRepresentative(ctx context.Context, tx *sql.Tx, problemIDs []int,
 	return highest, nil
 }
 
+// mostReferenced 후보 중 metadata.references 항목이 가장 많은 문제를 반환 (같으면 먼저 나온 후보)
+// references가 없거나 배열이 아니면 0개로 취급
+func mostReferenced(ctx context.Context, tx *sql.Tx, candidates []int) (int, error) {
+	query := `SELECT CASE WHEN jsonb_typeof(metadata::jsonb->'references') = 'array'
+				          THEN jsonb_array_length(metadata::jsonb->'references') ELSE 0 END
+			  FROM exercises
+			  WHERE metadata->>'mathflatProblemId' = $1 AND deleted_at IS NULL LIMIT 1`
+
+	best, bestCount := candidates[0], -1
+	for _, problemID := range candidates {
+		var count int
+		err := tx.QueryRowContext(ctx, query, strconv.Itoa(problemID)).Scan(&count)
+		if err != nil && err != sql.ErrNoRows {
+			return 0, fmt.Errorf("failed to count references of problem %d: %w", problemID, err)
+		}
+		if count > bestCount {
+			best, bestCount = problemID, count
+		}
+	}
+	return best, nil
+}
+
 type RepresentativeInfo struct {
 	ExerciseID       int64
 	ProblemID        int
 	HasSolutionVideo bool
 	SelectionReason  string
-}
\ No newline at end of file
+}
+
+// referencedExercise -group-by-reference로 찾은 문제
+type referencedExercise struct {
+	ExerciseID int64
+	ProblemID  string // metadata.mathflatProblemId (없으면 빈 문자열)
+	CategoryID sql.NullInt64
+}
+
+// reportByReference metadata.references 항목 중 match가 포함된(대소문자 무시) 항목을 찾아
+// 항목별로 해당 문제와 카테고리 경로를 출력 (읽기 전용)
+func reportByReference(db *sql.DB, match string) error {
+	ctx := context.Background()
+
+	// LIKE 특수문자는 글자 그대로 찾도록 이스케이프
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(match)
+	query := `SELECT r.ref, e.id, COALESCE(e.metadata->>'mathflatProblemId', ''), e.category_id
+			  FROM exercises e
+			  CROSS JOIN LATERAL jsonb_array_elements_text(
+			      CASE WHEN jsonb_typeof(e.metadata::jsonb->'references') = 'array'
+			           THEN e.metadata::jsonb->'references' ELSE '[]'::jsonb END) AS r(ref)
+			  WHERE e.deleted_at IS NULL AND r.ref ILIKE '%' || $1 || '%'
+			  ORDER BY r.ref, e.id`
+
+	rows, err := db.QueryContext(ctx, query, pattern)
+	if err != nil {
+		return fmt.Errorf("failed to query references: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []string
+	byRef := make(map[string][]referencedExercise)
+	for rows.Next() {
+		var ref string
+		var ex referencedExercise
+		if err := rows.Scan(&ref, &ex.ExerciseID, &ex.ProblemID, &ex.CategoryID); err != nil {
+			return fmt.Errorf("failed to scan references: %w", err)
+		}
+		if _, ok := byRef[ref]; !ok {
+			refs = append(refs, ref)
+		}
+		byRef[ref] = append(byRef[ref], ex)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to query references: %w", err)
+	}
+
+	paths := make(map[int64]string)
+	total := 0
+	for _, ref := range refs {
+		fmt.Printf("%s (%d exercises)\n", ref, len(byRef[ref]))
+		for _, ex := range byRef[ref] {
+			path := "-"
+			if ex.CategoryID.Valid {
+				if path, err = categoryPath(ctx, db, ex.CategoryID.Int64, paths); err != nil {
+					return err
+				}
+			}
+			problem := ex.ProblemID
+			if problem == "" {
+				problem = "-"
+			}
+			fmt.Printf("  exercise %d (problem %s): %s\n", ex.ExerciseID, problem, path)
+			total++
+		}
+	}
+	fmt.Printf("Found %d exercises in %d references matching %q\n", total, len(refs), match)
+	return nil
+}
+
+// categoryPath 최상위부터 "A > B > C" 형식의 카테고리 경로. 이미 구한 경로는 cache에서 재사용
+func categoryPath(ctx context.Context, db *sql.DB, categoryID int64, cache map[int64]string) (string, error) {
+	if path, ok := cache[categoryID]; ok {
+		return path, nil
+	}
+
+	var names []string
+	id := sql.NullInt64{Int64: categoryID, Valid: true}
+	// parent_id가 순환하더라도 멈추도록 깊이 제한
+	for depth := 0; id.Valid && depth < 32; depth++ {
+		var name string
+		err := db.QueryRowContext(ctx, `SELECT name, parent_id FROM categories WHERE id = $1`, id.Int64).Scan(&name, &id)
+		if err == sql.ErrNoRows {
+			names = append(names, fmt.Sprintf("#%d", id.Int64))
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get category %d: %w", id.Int64, err)
+		}
+		names = append(names, name)
+	}
+
+	// 부모 방향으로 모았으므로 뒤집음
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	path := strings.Join(names, " > ")
+	cache[categoryID] = path
+	return path, nil
+}
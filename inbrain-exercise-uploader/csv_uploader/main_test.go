@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRepresentativeFallbackIsRecorded(t *testing.T) {
+	tests := []struct {
+		name        string
+		chosenFound bool        // 선정한 대표 문제(11)가 새 그룹에 있는지
+		fallback    *fakeResult // 대체 대표 설정 쿼리의 결과
+		wantRep     int
+		wantChange  bool
+	}{
+		{"chosen representative found", true, nil, 11, false},
+		{"falls back to another problem", false, rows(row("12")), 12, true},
+		{"fallback without problem id", false, rows(row(nil)), 0, true},
+		{"group has no exercises", false, nil, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := openFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "SELECT category_id"):
+					return rows(row(int64(7))), nil
+				case strings.Contains(query, "INSERT INTO exercise_groups"):
+					return rows(row(int64(900))), nil
+				case strings.Contains(query, "SELECT id, CAST"):
+					// 교차 그룹 5의 기존 대표 문제 11 (해설 영상 있음)
+					return rows(row(int64(1), int64(11), true)), nil
+				case strings.Contains(query, "SET exercise_group_id") && args[1] == "11" && !tt.chosenFound:
+					return &fakeResult{}, nil
+				case strings.Contains(query, "mathflatProblemId' = $1 AND exercise_group_id = $2"):
+					if tt.chosenFound {
+						return nil, nil
+					}
+					return &fakeResult{}, nil
+				case strings.Contains(query, "RETURNING metadata"):
+					return tt.fallback, nil
+				}
+				return nil, nil
+			})
+
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = tx.Rollback() }()
+
+			result := CrossingResult{
+				NewGroupID:     51,
+				ProblemIDs:     []int{11, 12},
+				CrossingGroups: []CrossingGroup{{ID: 5, Intersection: []int{11, 12}}},
+			}
+			var report uploadReport
+			if err := processResult(context.Background(), tx, result, uploadOptions{RepresentativeStrategy: "default"}, &report); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := fake.count("RETURNING metadata"); got != map[bool]int{true: 0, false: 1}[tt.chosenFound] {
+				t.Errorf("fallback query ran %d times", got)
+			}
+			if !tt.wantChange {
+				if len(report.RepChanges) != 0 {
+					t.Errorf("RepChanges = %+v, want none", report.RepChanges)
+				}
+				return
+			}
+			want := []representativeChange{{
+				NewGroupID:              51,
+				GroupID:                 900,
+				CrossingGroupIDs:        []int{5},
+				PreviousRepresentatives: []int{11},
+				NewRepresentative:       tt.wantRep,
+			}}
+			if !reflect.DeepEqual(report.RepChanges, want) {
+				t.Errorf("RepChanges = %+v, want %+v", report.RepChanges, want)
+			}
+		})
+	}
+}
+
+func TestMostReferenced(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []int
+		references map[string]int64 // mathflatProblemId -> references 항목 수 (없으면 문제 없음)
+		want       int
+	}{
+		{"most references wins", []int{1, 2, 3}, map[string]int64{"1": 1, "2": 5, "3": 2}, 2},
+		{"tie keeps first candidate", []int{1, 2, 3}, map[string]int64{"1": 3, "2": 3, "3": 1}, 1},
+		{"missing problem counts as zero", []int{1, 2}, map[string]int64{"2": 1}, 2},
+		{"all zero keeps first candidate", []int{4, 5}, map[string]int64{"4": 0, "5": 0}, 4},
+		{"single candidate", []int{9}, map[string]int64{}, 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := openFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				count, ok := tt.references[args[0].(string)]
+				if !ok {
+					return nil, nil
+				}
+				return rows(row(count)), nil
+			})
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = tx.Rollback() }()
+
+			got, err := mostReferenced(context.Background(), tx, tt.candidates)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("mostReferenced(%v) = %d, want %d", tt.candidates, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnlyCrossingsSkipsOnlyWholeGroups(t *testing.T) {
+	results := []CrossingResult{
+		{NewGroupID: 51, ProblemIDs: []int{1, 2}, CrossingGroups: []CrossingGroup{{ID: 5, Intersection: []int{1, 2}}}},
+		{NewGroupID: 52, ProblemIDs: []int{7, 8}, CrossingGroups: []CrossingGroup{{ID: 6, Intersection: []int{7}}, {ID: 8, Intersection: []int{8}}}},
+		{NewGroupID: 53, ProblemIDs: []int{8, 9}, CrossingGroups: []CrossingGroup{{ID: 8, Intersection: []int{8, 9}}}},
+	}
+	tests := []struct {
+		name    string
+		result  CrossingResult
+		members int64 // 기존 그룹에 남은 문제 수
+		want    bool
+	}{
+		{"same as existing group", results[0], 2, true},
+		{"subset of existing group", results[0], 3, false},
+		{"group touched by another result", results[2], 2, false},
+		{"several crossing groups", results[1], 1, false},
+		{"problem outside the group", CrossingResult{ProblemIDs: []int{1, 3}, CrossingGroups: []CrossingGroup{{ID: 5, Intersection: []int{1}}}}, 2, false},
+	}
+	shared := sharedCrossingGroups(results)
+	if want := map[int]bool{8: true}; !reflect.DeepEqual(shared, want) {
+		t.Fatalf("sharedCrossingGroups = %v, want %v", shared, want)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, fake := openFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "SELECT COUNT(*) FROM exercises WHERE exercise_group_id"):
+					return rows(row(tt.members)), nil
+				case strings.Contains(query, "SELECT category_id"):
+					return rows(row(int64(7))), nil
+				case strings.Contains(query, "INSERT INTO exercise_groups"):
+					return rows(row(int64(900))), nil
+				}
+				return nil, nil
+			})
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = tx.Rollback() }()
+
+			opts := uploadOptions{OnlyCrossings: true, SkipRepresentative: true, SharedGroups: shared}
+			var report uploadReport
+			if err := processResult(context.Background(), tx, tt.result, opts, &report); err != nil {
+				t.Fatal(err)
+			}
+			if skipped := report.NoOpSkipped == 1; skipped != tt.want {
+				t.Errorf("skipped = %v, want %v", skipped, tt.want)
+			}
+			// 건너뛰지 않은 결과는 새 그룹으로 옮기고 기존 그룹을 삭제함
+			if moved := fake.count("INSERT INTO exercise_groups") == 1; moved == tt.want {
+				t.Errorf("new group created = %v, want %v", moved, !tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictFailureIsNotADBError(t *testing.T) {
+	tests := []struct {
+		name       string
+		updateErr  error // exercise_group_id 변경 쿼리의 오류
+		wantStrict bool
+	}{
+		{"problem not moved", nil, true},
+		{"database error", errors.New("connection reset by peer"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, _ := openFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				switch {
+				case strings.Contains(query, "SELECT category_id"):
+					return rows(row(int64(7))), nil
+				case strings.Contains(query, "INSERT INTO exercise_groups"):
+					return rows(row(int64(900))), nil
+				case strings.Contains(query, "SET exercise_group_id") && args[1] == "12":
+					// 문제 12는 DB에 없음
+					return &fakeResult{}, tt.updateErr
+				}
+				return nil, nil
+			})
+
+			results := []CrossingResult{{NewGroupID: 51, ProblemIDs: []int{11, 12}}}
+			var report uploadReport
+			err := uploadResults(db, results, uploadOptions{Strict: true, SkipRepresentative: true}, &report)
+			if err == nil {
+				t.Fatal("uploadResults succeeded, want error")
+			}
+			if got := errors.Is(err, errProblemsNotMoved); got != tt.wantStrict {
+				t.Errorf("errors.Is(%v, errProblemsNotMoved) = %v, want %v", err, got, tt.wantStrict)
+			}
+		})
+	}
+}
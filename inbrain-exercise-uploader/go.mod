@@ -7,6 +7,7 @@ require (
 	github.com/aws/aws-sdk-go-v2/config v1.31.8
 	github.com/aws/aws-sdk-go-v2/service/secretsmanager v1.39.4
 	github.com/lib/pq v1.10.9
+	github.com/unboxerscorp/utility/common v0.0.0
 )
 
 require (
@@ -22,3 +23,5 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/sts v1.38.4 // indirect
 	github.com/aws/smithy-go v1.23.0 // indirect
 )
+
+replace github.com/unboxerscorp/utility/common => ../common
@@ -0,0 +1,68 @@
+// Package safefile 사용자 입력 경로를 검증한 뒤 파일을 열거나 만드는 헬퍼
+package safefile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Clean 경로를 검증하고 정리된 경로를 반환
+// ".." 구성 요소가 있는 경로는 거부하고, root가 비어있지 않으면 root 하위 경로만 허용
+func Clean(filename, root string) (string, error) {
+	// 상대 경로 공격 방지 (a..b.csv 같은 이름은 허용)
+	if hasParentRef(filename) {
+		return "", errors.New("invalid file path: relative path not allowed")
+	}
+
+	cleanPath := filepath.Clean(filename)
+	if root == "" {
+		return cleanPath, nil
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid allow-root %s: %w", root, err)
+	}
+	absPath, err := filepath.Abs(cleanPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid file path %s: %w", filename, err)
+	}
+
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path: %s is outside of %s", filename, root)
+	}
+
+	return cleanPath, nil
+}
+
+// hasParentRef 경로에 ".." 구성 요소가 있으면 true
+func hasParentRef(filename string) bool {
+	for _, part := range strings.FieldsFunc(filename, func(r rune) bool { return r == '/' || r == filepath.Separator }) {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// Open Clean으로 검증한 경로의 파일을 읽기용으로 염
+func Open(filename, root string) (*os.File, error) {
+	cleanPath, err := Clean(filename, root)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(cleanPath)
+}
+
+// Create Clean으로 검증한 경로에 파일을 쓰기용으로 만듦 (있으면 비움)
+func Create(filename, root string) (*os.File, error) {
+	cleanPath, err := Clean(filename, root)
+	if err != nil {
+		return nil, err
+	}
+	return os.OpenFile(cleanPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+}
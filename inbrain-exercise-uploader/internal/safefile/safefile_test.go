@@ -0,0 +1,38 @@
+package safefile
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestClean(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name     string
+		filename string
+		root     string
+		wantErr  bool
+	}{
+		{"plain", "groups.csv", "", false},
+		{"double dot in name", "a..b.csv", "", false},
+		{"double dot in dir name", "out/v1..v2/result.json", "", false},
+		{"inside root", filepath.Join(root, "out", "result.json"), root, false},
+		{"root itself", root, root, false},
+		{"parent", "../groups.csv", "", true},
+		{"parent in middle", "data/../groups.csv", "", true},
+		{"trailing parent", "data/..", "", true},
+		{"outside root", filepath.Join(filepath.Dir(root), "other.json"), root, true},
+		{"sibling with root prefix", root + "-other/result.json", root, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Clean(tt.filename, tt.root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Clean(%q, %q) = %q, %v; wantErr %v", tt.filename, tt.root, got, err, tt.wantErr)
+			}
+			if err == nil && got != filepath.Clean(tt.filename) {
+				t.Errorf("Clean(%q) = %q, want %q", tt.filename, got, filepath.Clean(tt.filename))
+			}
+		})
+	}
+}
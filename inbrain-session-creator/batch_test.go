@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/unboxerscorp/utility/common/loglevel"
+)
+
+// fiftyLectureKeys 강의 50개가 있는 한 섹션
+func fiftyLectureKeys() []string {
+	keys := make([]string, 0, 50)
+	for n := 1; n <= 50; n++ {
+		keys = append(keys, fmt.Sprintf("세션/1_모듈/0_섹션/%d_강의%d.mp4", n, n))
+	}
+	return keys
+}
+
+func TestBatchInsertContentsMatchesPerRow(t *testing.T) {
+	keys := fiftyLectureKeys()
+	layout := func(batch bool) ([]string, int) {
+		h := newSessionHarness(t, nil, keys...)
+		h.p.batchContents = batch
+		h.run("세션", "세션")
+		// 두 번째 실행은 기존 콘텐츠를 찾아 아무것도 추가하지 않아야 함
+		h.run("세션", "세션")
+		_, contents := runLayout(h)
+		return contents, h.fake.count("learning_contents")
+	}
+
+	perRow, perRowQueries := layout(false)
+	batch, batchQueries := layout(true)
+	if len(perRow) != 50 {
+		t.Fatalf("per-row run created %d contents, want 50", len(perRow))
+	}
+	if !reflect.DeepEqual(batch, perRow) {
+		t.Errorf("batch contents = %v, want %v", batch, perRow)
+	}
+	if batchQueries >= perRowQueries {
+		t.Errorf("batch run used %d learning_contents queries, per-row %d", batchQueries, perRowQueries)
+	}
+}
+
+// go test -run '^$' -bench BatchInsertContents
+// content-queries/op가 섹션 하나를 처리하는 동안 learning_contents에 보낸 쿼리 수
+func BenchmarkBatchInsertContents(b *testing.B) {
+	// 파일마다 남는 진행 로그가 벤치마크 결과를 가리지 않도록 경고 이상만 출력
+	if err := loglevel.Set("warn"); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { _ = loglevel.Set("info") })
+
+	for _, batch := range []bool{false, true} {
+		b.Run(fmt.Sprintf("batch=%v", batch), func(b *testing.B) {
+			queries := 0
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				h := newSessionHarness(b, nil, fiftyLectureKeys()...)
+				h.p.batchContents = batch
+				b.StartTimer()
+
+				h.run("세션", "세션")
+				queries += h.fake.count("learning_contents")
+			}
+			b.ReportMetric(float64(queries)/float64(b.N), "content-queries/op")
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrimName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "single space", in: " ", want: ""},
+		{name: "zero-width space", in: "\u200b", want: ""},
+		{name: "mixed blanks", in: " \u200b\t\ufeff", want: ""},
+		{name: "surrounding blanks", in: "\u200b 점과 좌표 ", want: "점과 좌표"},
+		{name: "inner space kept", in: "점과 좌표", want: "점과 좌표"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimName(tt.in); got != tt.want {
+				t.Errorf("trimName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlankFoldersAreSkipped(t *testing.T) {
+	h := newSessionHarness(t, nil,
+		"세션/ /1_강의.mp4",
+		"세션/\u200b/1_강의.mp4",
+		"세션/1_함수/ /1_강의.mp4",
+		"세션/1_함수/\u200b/1_강의.mp4",
+		"세션/1_함수/0_극한\u200b /1_강의.mp4",
+	)
+
+	modules, err := h.p.GetModules("세션")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1_함수"}; !reflect.DeepEqual(modules, want) {
+		t.Errorf("GetModules = %q, want %q", modules, want)
+	}
+	sections, err := h.p.GetSections("세션", "1_함수")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"0_극한\u200b "}; !reflect.DeepEqual(sections, want) {
+		t.Errorf("GetSections = %q, want %q", sections, want)
+	}
+
+	h.run("세션", "세션")
+	if got, want := h.mem.sectionTitles(), []string{"극한|0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %q, want %q (names trimmed before insert)", got, want)
+	}
+	if got := h.mem.counts()["contents"]; got != 1 {
+		t.Errorf("%d contents created, want 1", got)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestClassifyFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"1_도입.mp4", "lecture"},
+		{"12_정리 영상.mov", "lecture"},
+		{"3_예제_해설_E12.mp4", "solution"},
+		{"해설_abc9.mov", "solution"},
+		// 이름 규칙에 맞지 않는 경우
+		{"weird name.mp4", ""},
+		{"도입.mp4", ""},
+		{"_도입.mp4", ""},
+		{"1_.mp4", ""},
+		{"1_도입.avi", ""},
+		{"1_도입.MP4", ""},
+		{"1_도입.mp4.bak", ""},
+		{"2_해설.mp4", ""},
+		{"2_해설_.mp4", ""},
+		{"2_해설_E-1.mp4", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if got := classifyFile(tt.filename); got != tt.want {
+				t.Errorf("classifyFile(%q) = %q, want %q", tt.filename, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnnamedFilesAreSkipped(t *testing.T) {
+	tests := []struct {
+		name         string
+		allowUnnamed bool
+		wantContents int
+	}{
+		{name: "skipped by default", allowUnnamed: false, wantContents: 2},
+		{name: "processed with -allow-unnamed", allowUnnamed: true, wantContents: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newSessionHarness(t, []string{"E1"},
+				"세션/1_함수/0_극한/1_도입.mp4",
+				"세션/1_함수/0_극한/2_예제_해설_E1.mp4",
+				"세션/1_함수/0_극한/weird name.mp4",
+			)
+			h.p.allowUnnamed = tt.allowUnnamed
+			h.run("세션", "세션")
+
+			if got := h.mem.counts()["contents"]; got != tt.wantContents {
+				t.Errorf("%d contents created, want %d", got, tt.wantContents)
+			}
+			if len(h.p.failedFiles) != 0 {
+				t.Errorf("failed files = %v, want none", h.p.failedFiles)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// withStdin 테스트 동안 input을 표준 입력으로 사용 (prompt 응답)
+func withStdin(t *testing.T, input string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+
+	saved := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() {
+		os.Stdin = saved
+		_ = r.Close()
+	})
+}
+
+func TestResolveExisting(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      string
+		interactive bool
+		input       string
+		wantReuse   bool
+		wantErr     error
+		wantAnyErr  bool
+	}{
+		{name: "reuse", policy: "reuse", interactive: true, wantReuse: true},
+		{name: "skip", policy: "skip", interactive: true, wantErr: errSkipExisting},
+		{name: "skip module", policy: "skip", interactive: false, wantErr: errSkipExisting},
+		{name: "new", policy: "new", interactive: true, wantReuse: false},
+		{name: "prompt module reuses without asking", policy: "prompt", interactive: false, wantReuse: true},
+		{name: "prompt answered y", policy: "prompt", interactive: true, input: "y\n", wantReuse: true},
+		{name: "prompt answered Y", policy: "prompt", interactive: true, input: "Y\n", wantReuse: true},
+		{name: "prompt answered n", policy: "prompt", interactive: true, input: "n\n", wantAnyErr: true},
+		{name: "prompt without answer", policy: "prompt", interactive: true, input: "", wantAnyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withStdin(t, tt.input)
+			p := newTestParser()
+			p.onExisting = tt.policy
+
+			reuse, err := p.resolveExisting("세션", 42, "세션", tt.interactive)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantAnyErr:
+				if err == nil || errors.Is(err, errSkipExisting) {
+					t.Fatalf("err = %v, want cancellation", err)
+				}
+			case err != nil:
+				t.Fatalf("err = %v", err)
+			}
+			if reuse != tt.wantReuse {
+				t.Errorf("reuse = %v, want %v", reuse, tt.wantReuse)
+			}
+		})
+	}
+}
+
+func TestOnExistingSecondRun(t *testing.T) {
+	keys := []string{
+		"세션/1_함수/0_극한/1_도입.mp4",
+		"세션/1_함수/1_연속/1_도입.mp4",
+	}
+	tests := []struct {
+		policy string
+		input  string
+		// 두 번째 실행 뒤 첫 실행 대비 행 수 배율
+		wantFactor int
+	}{
+		{policy: "reuse", wantFactor: 1},
+		{policy: "skip", wantFactor: 1},
+		{policy: "new", wantFactor: 2},
+		{policy: "prompt", input: "y\n", wantFactor: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			h := newSessionHarness(t, nil, keys...)
+			h.run("세션", "세션")
+			first := h.mem.counts()
+			queries := h.fake.count("")
+
+			withStdin(t, tt.input)
+			h.p.onExisting = tt.policy
+			h.run("세션", "세션")
+
+			want := make(map[string]int)
+			for table, n := range first {
+				want[table] = n * tt.wantFactor
+			}
+			if got := h.mem.counts(); !reflect.DeepEqual(got, want) {
+				t.Errorf("rows after second run = %v, want %v", got, want)
+			}
+			// skip은 세션 확인 쿼리 하나만 보내고 모듈/섹션은 보지 않음
+			if tt.policy == "skip" {
+				if got := h.fake.count("") - queries; got != 1 {
+					t.Errorf("skip sent %d queries, want 1", got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDB 테스트용 database/sql 드라이버. 실행된 쿼리를 기록하고 결과는 handle이 정함
+// handle이 nil을 반환하면 빈 결과 (QueryRow는 sql.ErrNoRows, Exec는 1행 변경)
+type fakeDB struct {
+	mu      sync.Mutex
+	handle  func(query string, args []driver.Value) (*fakeResult, error)
+	queries []fakeQuery
+	stmts   int // 열려 있는 prepared statement 수
+}
+
+type fakeQuery struct {
+	SQL  string
+	Args []driver.Value
+}
+
+type fakeResult struct {
+	Columns []string
+	Rows    [][]driver.Value
+}
+
+var (
+	fakeDBsMu sync.Mutex
+	fakeDBs   = make(map[string]*fakeDB)
+)
+
+func init() {
+	sql.Register("fakedb", fakeDriver{})
+}
+
+// openFakeDB handle로 응답하는 *sql.DB를 열고 테스트가 끝나면 닫음
+func openFakeDB(t testing.TB, handle func(query string, args []driver.Value) (*fakeResult, error)) (*sql.DB, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{handle: handle}
+
+	fakeDBsMu.Lock()
+	name := fmt.Sprintf("%s/%d", t.Name(), len(fakeDBs))
+	fakeDBs[name] = f
+	fakeDBsMu.Unlock()
+
+	db, err := sql.Open("fakedb", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db, f
+}
+
+// count substr을 포함한 쿼리가 실행된 횟수
+func (f *fakeDB) count(substr string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, q := range f.queries {
+		if strings.Contains(q.SQL, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+// find substr을 포함한 쿼리들
+func (f *fakeDB) find(substr string) []fakeQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []fakeQuery
+	for _, q := range f.queries {
+		if strings.Contains(q.SQL, substr) {
+			found = append(found, q)
+		}
+	}
+	return found
+}
+
+func (f *fakeDB) openStmts() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stmts
+}
+
+func (f *fakeDB) run(query string, args []driver.Value) (*fakeResult, error) {
+	f.mu.Lock()
+	f.queries = append(f.queries, fakeQuery{SQL: query, Args: args})
+	handle := f.handle
+	f.mu.Unlock()
+
+	if handle == nil {
+		return nil, nil
+	}
+	return handle(query, args)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeDBsMu.Lock()
+	defer fakeDBsMu.Unlock()
+	f, ok := fakeDBs[name]
+	if !ok {
+		return nil, fmt.Errorf("fakedb: unknown database %s", name)
+	}
+	return &fakeConn{db: f}, nil
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.db.mu.Lock()
+	c.db.stmts++
+	c.db.mu.Unlock()
+	return &fakeStmt{db: c.db, query: query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+func (c *fakeConn) BeginTx(_ context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	return fakeTx{}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db     *fakeDB
+	query  string
+	closed bool
+}
+
+func (s *fakeStmt) Close() error {
+	if !s.closed {
+		s.closed = true
+		s.db.mu.Lock()
+		s.db.stmts--
+		s.db.mu.Unlock()
+	}
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	res, err := s.db.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return driver.RowsAffected(1), nil
+	}
+	return driver.RowsAffected(len(res.Rows)), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	res, err := s.db.run(s.query, args)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		res = &fakeResult{}
+	}
+	return &fakeRows{res: res}, nil
+}
+
+type fakeRows struct {
+	res *fakeResult
+	pos int
+}
+
+func (r *fakeRows) Columns() []string {
+	if r.res.Columns == nil && len(r.res.Rows) > 0 {
+		// 컬럼 이름이 필요 없는 테스트는 개수만 맞춤
+		return make([]string, len(r.res.Rows[0]))
+	}
+	return r.res.Columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.res.Rows) {
+		return io.EOF
+	}
+	copy(dest, r.res.Rows[r.pos])
+	r.pos++
+	return nil
+}
+
+// rows 테스트에서 결과를 짧게 쓰기 위한 헬퍼
+func rows(values ...[]driver.Value) *fakeResult {
+	return &fakeResult{Rows: values}
+}
+
+func row(values ...driver.Value) []driver.Value {
+	return values
+}
@@ -8,6 +8,8 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.88.1
 	github.com/google/uuid v1.6.0
 	github.com/lib/pq v1.10.9
+	github.com/unboxerscorp/utility/common v0.0.0
+	golang.org/x/text v0.29.0
 )
 
 require (
@@ -27,3 +29,5 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/sts v1.38.4 // indirect
 	github.com/aws/smithy-go v1.23.0 // indirect
 )
+
+replace github.com/unboxerscorp/utility/common => ../common
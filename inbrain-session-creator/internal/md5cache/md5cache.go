@@ -0,0 +1,101 @@
+// Package md5cache S3 객체의 MD5를 실행 간에 보관하는 JSON 파일 캐시
+//
+// 키는 "버킷/키"이고 ETag를 함께 저장해, 같은 키에 다른 파일이 올라가 ETag가 바뀌면 무효로 봄.
+// 파일 형식: {"버킷/키": {"etag": "...", "md5": "..."}, ...} (다른 도구에서도 읽고 쓸 수 있음)
+package md5cache
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// FileName 캐시 디렉토리 안의 캐시 파일 이름
+const FileName = "md5-cache.json"
+
+type entry struct {
+	ETag string `json:"etag"`
+	MD5  string `json:"md5"`
+}
+
+// Cache 여러 고루틴에서 함께 쓸 수 있는 MD5 캐시
+type Cache struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]entry
+}
+
+// Open dir의 캐시 파일을 읽음. 디렉토리나 파일이 없으면 빈 캐시로 시작하고 첫 Put에서 만듦
+func Open(dir string) (*Cache, error) {
+	// 상대 경로 공격 방지
+	if strings.Contains(dir, "..") {
+		return nil, errors.New("invalid cache dir: relative path not allowed")
+	}
+
+	c := &Cache{
+		path:    filepath.Join(filepath.Clean(dir), FileName),
+		entries: make(map[string]entry),
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return c, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Len 캐시된 항목 수
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Get key의 MD5를 반환. 캐시에 없거나 저장된 ETag가 etag와 다르면 ok=false
+// (ETag가 다르면 stale=true이고, 다음 Put이 항목을 덮어씀)
+func (c *Cache) Get(key, etag string) (md5 string, ok, stale bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, exists := c.entries[key]
+	if !exists {
+		return "", false, false
+	}
+	if e.ETag != etag {
+		return "", false, true
+	}
+	return e.MD5, true, false
+}
+
+// Put key의 MD5를 기록하고 바로 파일에 씀 (중단되어도 계산한 값은 남도록)
+func (c *Cache) Put(key, etag, md5 string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = entry{ETag: etag, MD5: md5}
+	return c.save()
+}
+
+// save 임시 파일에 쓴 뒤 이름을 바꿔, 쓰는 도중 중단되어도 이전 캐시 파일이 깨지지 않게 함
+func (c *Cache) save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
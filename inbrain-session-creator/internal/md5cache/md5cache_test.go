@@ -0,0 +1,94 @@
+package md5cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGet(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Put("videos/lectures/1_도입.mp4", `"etag-1"`, "md5-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		key       string
+		etag      string
+		wantMD5   string
+		wantOK    bool
+		wantStale bool
+	}{
+		{name: "hit", key: "videos/lectures/1_도입.mp4", etag: `"etag-1"`, wantMD5: "md5-1", wantOK: true},
+		{name: "miss", key: "videos/lectures/2_정리.mp4", etag: `"etag-1"`},
+		{name: "same key in another bucket", key: "other/lectures/1_도입.mp4", etag: `"etag-1"`},
+		{name: "etag changed", key: "videos/lectures/1_도입.mp4", etag: `"etag-2"`, wantStale: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md5, ok, stale := c.Get(tt.key, tt.etag)
+			if md5 != tt.wantMD5 || ok != tt.wantOK || stale != tt.wantStale {
+				t.Errorf("Get(%s, %s) = (%q, %v, %v), want (%q, %v, %v)", tt.key, tt.etag, md5, ok, stale, tt.wantMD5, tt.wantOK, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestPutInvalidatesAndPersists(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache")
+	c, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 0 {
+		t.Fatalf("new cache has %d entries", c.Len())
+	}
+
+	const key = "videos/lectures/1_도입.mp4"
+	if err := c.Put(key, `"etag-1"`, "md5-1"); err != nil {
+		t.Fatal(err)
+	}
+	// 같은 키에 다른 파일이 올라가 다시 계산한 값으로 덮어씀
+	if err := c.Put(key, `"etag-2"`, "md5-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reopened.Len() != 1 {
+		t.Errorf("reopened cache has %d entries, want 1", reopened.Len())
+	}
+	if _, ok, stale := reopened.Get(key, `"etag-1"`); ok || !stale {
+		t.Errorf("old etag: ok=%v stale=%v, want a stale miss", ok, stale)
+	}
+	if md5, ok, _ := reopened.Get(key, `"etag-2"`); !ok || md5 != "md5-2" {
+		t.Errorf("new etag: (%q, %v), want (md5-2, true)", md5, ok)
+	}
+	if _, err := os.Stat(filepath.Join(dir, FileName+".tmp")); !os.IsNotExist(err) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("relative path rejected", func(t *testing.T) {
+		if _, err := Open("../cache"); err == nil {
+			t.Error("Open(../cache) succeeded")
+		}
+	})
+	t.Run("corrupt file", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, FileName), []byte("{not json"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(dir); err == nil {
+			t.Error("Open succeeded on a corrupt cache file")
+		}
+	})
+}
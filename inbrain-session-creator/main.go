@@ -4,11 +4,14 @@ import (
 	"context"
 	"crypto/md5" //nolint:gosec
 	"database/sql"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
@@ -18,20 +21,37 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 
 	"github.com/google/uuid"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/unboxerscorp/utility/common/envflag"
+	"github.com/unboxerscorp/utility/common/loglevel"
+	"github.com/unboxerscorp/utility/inbrain-session-creator/internal/md5cache"
 )
 
 const (
 	// CloudFront 설정
 	cloudfrontBaseURL = "https://media.basemath.co.kr"
 
+	// -probe-via-s3로 만드는 presigned GET URL 유효 시간
+	presignExpiry = time.Hour
+
+	// 파일별 비디오 생성 재시도 기본 간격 (시도할 때마다 늘어남)
+	fileRetryDelay = 5 * time.Second
+
+	// -transcode의 동시 변환 수. libx264가 코어를 모두 쓰므로 하나씩 변환
+	transcodeConcurrency = 1
+
 	// 고정값
 	lecturesCategoryID = 526
 	sessionSequence    = 0
@@ -41,11 +61,154 @@ const (
 type Parser struct {
 	db                *sql.DB
 	s3Client          *s3.Client
+	presignClient     *s3.PresignClient
 	ctx               context.Context
 	bucketName        string
 	region            string
 	forceReplaceVideo bool
 	testExam          bool
+	onExisting        string // 같은 세션/모듈/섹션이 이미 있을 때: reuse, skip, new, prompt
+	allowUnnamed      bool
+	batchContents     bool
+	reconcile         bool // 개수 비교 대신 파일별로 DB 콘텐츠의 S3 키와 비교해 누락/다른 파일만 처리
+	strictNumbering   bool
+	defaultModuleType string
+	sortMode          string
+	probeSource       string
+	titleTemplate     string
+	saveProbeDir      string
+	outputRoot        string
+
+	// S3 키+ETag별 MD5 캐시 (nil이면 매번 다운로드해서 계산)
+	md5Cache *md5cache.Cache
+
+	// DB(source_url)에 저장할 URL의 기본 주소. ffprobe/ffmpeg/MD5는 cloudfrontBaseURL에서 읽음
+	storedBaseURL string
+
+	// ffprobe/ffmpeg/MD5를 CloudFront 대신 S3 presigned URL로 읽음 (CDN 매핑 전 비공개 콘텐츠용)
+	probeViaS3 bool
+
+	// .mov를 .mp4로 변환해 원본 옆에 올리고 source_url로 .mp4를 저장
+	transcode bool
+
+	// 썸네일 업로드 위치 (기본: 영상과 같은 버킷, 영상 옆)
+	thumbnailBucket  string
+	forceThumbnails  bool // 썸네일 위치에 이미 객체가 있어도 다시 생성
+	thumbnailPrefix  string
+	thumbnailBaseURL string
+
+	// 섹션 폴더 없이 모듈 바로 아래 있는 파일들을 담을 기본 섹션
+	defaultSectionName     string
+	defaultSectionSequence int
+
+	// 파일별 재시도 횟수와 간격, 재시도 후에도 실패한 파일 목록 (dead letter)
+	maxRetriesPerFile int
+	fileRetryDelay    time.Duration
+	failedMu          sync.Mutex
+	failedFiles       []failedFile
+	failedKeys        map[string]bool // 어느 단계에서든 실패한 S3 키 (-dump-failed-urls)
+
+	// S3 키별로 생성/사용한 video, learning_content ID (-id-map)
+	idMapMu   sync.Mutex
+	idMapRows []idMapRow
+
+	// 동시에 처리할 섹션 수 (1이면 순차 처리)
+	parallelSections int
+
+	// ffprobe/ffmpeg/MD5 등 CloudFront를 읽는 작업과 S3 업로드의 동시 실행 수 제한
+	probeSem  chan struct{}
+	uploadSem chan struct{}
+
+	// -transcode의 ffmpeg 변환 동시 실행 수 제한 (오래 걸리므로 probeSem과 따로 둠)
+	transcodeSem chan struct{}
+
+	// CloudFront 요청 속도 제한 (nil이면 제한 없음)
+	cdnLimiter *rateLimiter
+
+	// 파일마다 실행하는 쿼리의 prepared statement (쿼리 문자열 -> statement, Close에서 닫음)
+	stmtMu sync.Mutex
+	stmts  map[string]*sql.Stmt
+
+	// -metrics-addr로 노출하는 진행 상황 (서버가 없어도 항상 기록)
+	progress      *progressTracker
+	metricsServer *http.Server
+}
+
+// rateLimiter 초당 요청 수 제한 (버스트 1인 토큰 버킷)
+type rateLimiter struct {
+	ticker *time.Ticker
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	return &rateLimiter{ticker: time.NewTicker(time.Duration(float64(time.Second) / rps))}
+}
+
+// Wait 토큰을 얻을 때까지 대기
+func (r *rateLimiter) Wait() {
+	if r == nil {
+		return
+	}
+	<-r.ticker.C
+}
+
+// Stop 티커를 멈춤. 이후 Wait를 부르면 안 됨
+func (r *rateLimiter) Stop() {
+	if r == nil {
+		return
+	}
+	r.ticker.Stop()
+}
+
+// failedFile 재시도 한도를 넘겨 격리된 파일
+// Fallback이면 격리되지 않고 대체 처리로 계속 진행한 파일 (예: mp4 변환 실패로 원본 .mov 사용)
+type failedFile struct {
+	S3Path   string
+	Err      error
+	Fallback bool
+}
+
+// idMapRow S3 파일과 연결된 video/learning_content ID. 알 수 없는 ID는 0
+type idMapRow struct {
+	S3Key       string
+	VideoID     int64
+	ContentID   int64
+	ContentType string
+}
+
+// ParserOptions Parser 동작 옵션
+type ParserOptions struct {
+	ForceReplaceVideo bool
+	TestExam          bool
+	NoReuse           bool
+	OnExisting        string
+	AllowUnnamed      bool
+	BatchContents     bool
+	Reconcile         bool
+	StrictNumbering   bool
+	ProbeConcurrency  int
+	UploadConcurrency int
+	CloudFrontRPS     float64
+	DefaultModuleType string
+	SortMode          string
+	ProbeSource       string
+	TitleTemplate     string
+	SaveProbeDir      string
+	OutputRoot        string
+	MD5CacheDir       string
+
+	StoredBaseURL    string
+	ProbeViaS3       bool
+	Transcode        bool
+	ThumbnailBucket  string
+	ForceThumbnails  bool
+	ThumbnailPrefix  string
+	ThumbnailBaseURL string
+
+	DefaultSectionName     string
+	DefaultSectionSequence int
+
+	MaxRetriesPerFile int
+	ParallelSections  int
 }
 
 type SessionInfo struct {
@@ -67,6 +230,23 @@ type SectionInfo struct {
 	Sequence int
 }
 
+// envFlags 환경변수/.env로 지정할 수 있는 플래그 (플래그 이름 -> 환경변수 이름)
+// 접속 정보와 주소만 허용. -delete, -merge-duplicate-videos, -video-where처럼 작업 종류나 대상을 바꾸는 플래그는
+// 셸이나 .env에 남은 변수로 켜지지 않도록 명시적으로만 지정
+var envFlags = map[string]string{
+	"db-host":            "DB_HOST",
+	"db-port":            "DB_PORT",
+	"db-user":            "DB_USER",
+	"db-password":        "DB_PASSWORD",
+	"db-name":            "DB_NAME",
+	"db-ssl":             "DB_SSL",
+	"s3-bucket":          "S3_BUCKET",
+	"s3-region":          "S3_REGION",
+	"stored-base-url":    "STORED_BASE_URL",
+	"thumbnail-bucket":   "THUMBNAIL_BUCKET",
+	"thumbnail-base-url": "THUMBNAIL_BASE_URL",
+}
+
 func main() {
 	// 명령줄 인자 파싱
 	var sessionName string
@@ -81,9 +261,59 @@ func main() {
 	var s3Region string
 	var forceReplaceVideo bool
 	var testExam bool
+	var noReuse bool
+	var onExisting string
+	var allowUnnamed bool
+	var strictNumbering bool
+	var batchInsertContents bool
+	var reconcile bool
+	var checkOrphanVideos bool
+	var checkOrphanContents bool
+	var deleteOrphans bool
+	var fixNormalization bool
+	var mergeDuplicateVideos bool
+	var dedupeVideosBySourceURL bool
+	var regenerateThumbnails bool
+	var videoIDs string
+	var videoWhere string
+	var thumbnailAt string
+	var previewThumbnail string
+	var previewOut string
+	var afterID int64
+	var listSessions bool
+	var filterStudentID int
+	var titleLike string
+	var batchSize int
+	var envFile string
+	var probeConcurrency int
+	var uploadConcurrency int
+	var cloudfrontRPS float64
+	var defaultModuleType string
+	var sortMode string
+	var probeSource string
+	var titleTemplate string
+	var saveProbeDir string
+	var outputRoot string
+	var md5CacheDir string
+	var storedBaseURL string
+	var probeViaS3 bool
+	var transcode bool
+	var forceThumbnails bool
+	var thumbnailBucket, thumbnailPrefix, thumbnailBaseURL string
+	var defaultSectionName string
+	var defaultSectionSequence int
+	var maxRetriesPerFile int
+	var parallelSections int
+	var failedFilesOut string
+	var metricsAddr string
+	var dumpFailedURLs string
+	var idMapOut string
+	var s3PrefixGlob string
+	var logLevel string
 
 	flag.StringVar(&sessionName, "session", "", "세션 이름 (예: '공통수학2 Day1')")
 	flag.StringVar(&s3Prefix, "s3-prefix", "", "S3 폴더명 (예: '공통수학2 Day1')")
+	flag.StringVar(&s3PrefixGlob, "s3-prefix-glob", "", "lectures/ 아래 이 패턴에 맞는 폴더를 모두 각각의 세션으로 처리 (예: '공통수학2 Day*')")
 	flag.StringVar(&dbHost, "db-host", "localhost", "데이터베이스 호스트")
 	flag.IntVar(&dbPort, "db-port", 5432, "데이터베이스 포트")
 	flag.StringVar(&dbUser, "db-user", "postgres", "데이터베이스 사용자")
@@ -94,17 +324,178 @@ func main() {
 	flag.StringVar(&s3Region, "s3-region", "ap-northeast-2", "S3 리전")
 	flag.BoolVar(&forceReplaceVideo, "force-replace-video", false, "기존 비디오를 강제로 대체")
 	flag.BoolVar(&testExam, "test-exam", false, "연습 문제에 비디오 매핑하지 않음")
+	flag.BoolVar(&noReuse, "no-reuse", false, "같은 타이틀의 세션/모듈/섹션이 있어도 재사용하지 않고 새로 생성 (-on-existing=new와 같음)")
+	flag.StringVar(&onExisting, "on-existing", "prompt", "같은 세션/모듈/섹션이 이미 있을 때 (reuse: 재사용, skip: 건너뜀, new: 새로 생성, prompt: 세션만 확인 후 재사용)")
+	flag.BoolVar(&allowUnnamed, "allow-unnamed", false, "강의(N_제목.mov)/해설(..._해설_ID.mov) 이름 규칙에 맞지 않는 파일도 처리")
+	flag.BoolVar(&reconcile, "reconcile", false, "섹션의 S3 파일과 DB 콘텐츠 수가 같아도 건너뛰지 않고, 같은 sequence의 콘텐츠가 없거나 다른 S3 키의 영상을 가리키는 파일만 처리")
+	flag.BoolVar(&batchInsertContents, "batch-insert-contents", false, "섹션의 learning_contents를 모아 여러 행 INSERT로 한 번에 생성 (-force-replace-video와 함께 사용 불가)")
+	flag.BoolVar(&strictNumbering, "strict-numbering", false, "섹션 내 파일 번호가 섞여 있거나 중복/누락되면 세션을 만들지 않고 중단 (기본: 경고만)")
+	flag.IntVar(&parallelSections, "parallel-sections", 1, "동시에 처리할 섹션 수 (모듈/섹션 생성은 순차)")
+	flag.IntVar(&probeConcurrency, "probe-concurrency", 4, "동시에 실행할 ffprobe/ffmpeg/MD5 작업 수 (CloudFront 부하)")
+	flag.IntVar(&uploadConcurrency, "upload-concurrency", 8, "동시에 실행할 S3 업로드 수")
+	flag.Float64Var(&cloudfrontRPS, "cloudfront-rps", 0, "CloudFront 요청(ffprobe/ffmpeg/MD5) 초당 최대 수 (0이면 제한 없음)")
+	flag.StringVar(&defaultModuleType, "default-module-type", "", "모듈 타입을 판별할 수 없을 때 사용할 타입 (concept, pattern, exam). 비어있으면 unknown")
+	flag.StringVar(&sortMode, "sort", "sequence", "섹션 내 파일 정렬 방식 (sequence: 파일명 앞 번호, key: S3 키 사전순)")
+	flag.StringVar(&probeSource, "probe-source", "format", "영상 길이로 쓸 ffprobe 값 (format: 컨테이너, stream: 비디오 스트림, max: 둘 중 큰 값)")
+	flag.StringVar(&titleTemplate, "title-template", "{filename}", "비디오/강의 제목 템플릿 ({module}, {section}, {filename}, {n})")
+	flag.StringVar(&saveProbeDir, "save-probe-dir", "", "ffprobe 전체 JSON 출력을 S3 키별로 저장할 디렉토리 (디버깅용, 비어있으면 저장 안 함)")
+	flag.StringVar(&outputRoot, "output-root", "", "출력 파일(-failed-files-out, -dump-failed-urls, -id-map, -save-probe-dir, -preview-out)을 쓸 수 있는 디렉토리 (비어있으면 제한 없음)")
+	flag.StringVar(&storedBaseURL, "stored-base-url", "", "DB에 저장할 source_url/thumbnail_url의 기본 URL (CDN 이전용, 비어있으면 "+cloudfrontBaseURL+"). 영상은 계속 "+cloudfrontBaseURL+"에서 읽음")
+	flag.BoolVar(&probeViaS3, "probe-via-s3", false, "ffprobe/ffmpeg/MD5를 CloudFront 대신 S3 presigned URL로 읽음 (DB에는 계속 CloudFront URL 저장)")
+	flag.BoolVar(&transcode, "transcode", false, ".mov 영상을 .mp4로 변환해 원본 옆에 업로드하고 source_url로 .mp4 저장 (변환 실패 시 원본 사용)")
+	flag.StringVar(&md5CacheDir, "md5-cache-dir", "", "S3 키+ETag별 MD5를 실행 간에 보관할 디렉토리 (비어있으면 매번 다운로드해서 계산)")
+	flag.BoolVar(&forceThumbnails, "force-thumbnails", false, "썸네일 위치에 이미 객체가 있어도 ffmpeg로 다시 생성해 덮어씀")
+	flag.StringVar(&thumbnailBucket, "thumbnail-bucket", "", "썸네일을 업로드할 S3 버킷 (비어있으면 s3-bucket)")
+	flag.StringVar(&thumbnailPrefix, "thumbnail-prefix", "", "썸네일 S3 키 앞에 붙일 prefix (비어있으면 영상 옆에 저장)")
+	flag.StringVar(&thumbnailBaseURL, "thumbnail-base-url", "", "thumbnail_url을 만들 때 쓸 기본 URL (비어있으면 stored-base-url)")
+	flag.StringVar(&defaultSectionName, "default-section-name", "기본", "섹션 폴더 없이 모듈 바로 아래 파일이 있을 때 만들 섹션 이름")
+	flag.IntVar(&defaultSectionSequence, "default-section-sequence", 0, "기본 섹션의 sequence")
+	flag.IntVar(&maxRetriesPerFile, "max-retries-per-file", 2, "파일별 비디오 생성 재시도 횟수 (초과하면 실패 목록으로 격리)")
+	flag.StringVar(&metricsAddr, "metrics-addr", "", "진행 상황을 /progress(JSON)와 /healthz로 제공할 HTTP 주소 (예: :9090, 비어있으면 사용 안 함)")
+	flag.StringVar(&failedFilesOut, "failed-files-out", "", "실패한 파일의 S3 키를 기록할 파일 (비어있으면 출력만)")
+	flag.StringVar(&dumpFailedURLs, "dump-failed-urls", "", "어느 단계에서든 실패한 파일의 CloudFront URL을 한 줄씩 기록할 파일")
+	flag.StringVar(&idMapOut, "id-map", "", "S3 키별 video_id, content_id를 기록할 CSV 파일 (비어있으면 기록 안 함)")
+	flag.BoolVar(&checkOrphanVideos, "check-orphan-videos", false, "참조되지 않는 비디오 조회 (유지보수)")
+	flag.BoolVar(&checkOrphanContents, "check-orphan-contents", false, "참조하는 강의/연습문제/비디오가 없거나 삭제된 콘텐츠 조회 (유지보수)")
+	flag.BoolVar(&fixNormalization, "fix-normalization", false, "DB의 NFD URL을 S3에 존재하는 NFC 키로 수정 (유지보수)")
+	flag.BoolVar(&mergeDuplicateVideos, "merge-duplicate-videos", false, "같은 MD5의 중복 비디오를 하나로 병합 (유지보수)")
+	flag.BoolVar(&dedupeVideosBySourceURL, "dedupe-videos-by-source-url", false, "같은 source_url의 중복 비디오를 하나로 병합 (MD5 없는 비디오용, 유지보수)")
+	flag.BoolVar(&regenerateThumbnails, "regenerate-thumbnails", false, "DB의 비디오 썸네일 재생성 (유지보수)")
+	flag.StringVar(&videoIDs, "video-ids", "", "regenerate-thumbnails 대상 비디오 ID 목록 (예: 1,2,3)")
+	flag.StringVar(&videoWhere, "video-where", "", "regenerate-thumbnails 대상 SQL 조건 (videos 테이블 별칭 v, 예: \"v.thumbnail_url IS NULL\")")
+	flag.StringVar(&thumbnailAt, "thumbnail-at", "", "썸네일로 사용할 시점 (ffmpeg -ss 형식, 예: 5 또는 00:00:05, 비어있으면 첫 프레임)")
+	flag.StringVar(&previewThumbnail, "preview-thumbnail", "", "영상 URL의 -thumbnail-at 시점 프레임을 로컬 파일로만 추출 (S3/DB 사용 안 함)")
+	flag.StringVar(&previewOut, "preview-out", "", "preview-thumbnail 출력 PNG 경로 (비어있으면 /tmp에 생성)")
+	flag.Int64Var(&afterID, "after-id", 0, "이 ID 이후의 비디오부터 처리 (중단된 작업 재개용)")
+	flag.BoolVar(&listSessions, "list-sessions", false, "삭제되지 않은 세션 목록 조회 (유지보수)")
+	flag.IntVar(&filterStudentID, "student-id", 0, "list-sessions에서 조회할 학생 ID (0이면 전체)")
+	flag.StringVar(&titleLike, "title-like", "", "list-sessions에서 타이틀에 포함될 문자열")
+	flag.BoolVar(&deleteOrphans, "delete", false, "유지보수 명령에서 조회된 항목을 실제로 soft delete")
+	flag.IntVar(&batchSize, "batch-size", 500, "유지보수 명령의 배치 크기")
+	flag.StringVar(&logLevel, "log-level", "info", "진행 로그 수준 ("+loglevel.Names+"). warn이면 파일별 진행 로그를 숨기고 경고만 출력")
+	flag.StringVar(&envFile, "env-file", ".env", "설정을 읽을 .env 파일 (없으면 무시)")
 	flag.Parse()
 
+	// 명시하지 않은 접속 정보/주소 플래그는 환경변수 > .env 파일 순으로 채움
+	if err := envflag.Bind(flag.CommandLine, envFile, envFlags); err != nil {
+		log.Fatal("환경 설정 로드 실패:", err)
+	}
+	if err := loglevel.Set(logLevel); err != nil {
+		log.Fatal(err)
+	}
+
+	opts := ParserOptions{
+		ForceReplaceVideo: forceReplaceVideo,
+		TestExam:          testExam,
+		NoReuse:           noReuse,
+		OnExisting:        onExisting,
+		AllowUnnamed:      allowUnnamed,
+		BatchContents:     batchInsertContents,
+		Reconcile:         reconcile,
+		StrictNumbering:   strictNumbering,
+		ProbeConcurrency:  probeConcurrency,
+		UploadConcurrency: uploadConcurrency,
+		CloudFrontRPS:     cloudfrontRPS,
+		DefaultModuleType: defaultModuleType,
+		SortMode:          sortMode,
+		ProbeSource:       probeSource,
+		TitleTemplate:     titleTemplate,
+		SaveProbeDir:      saveProbeDir,
+		OutputRoot:        outputRoot,
+		MD5CacheDir:       md5CacheDir,
+
+		StoredBaseURL:    storedBaseURL,
+		ProbeViaS3:       probeViaS3,
+		Transcode:        transcode,
+		ThumbnailBucket:  thumbnailBucket,
+		ForceThumbnails:  forceThumbnails,
+		ThumbnailPrefix:  thumbnailPrefix,
+		ThumbnailBaseURL: thumbnailBaseURL,
+
+		DefaultSectionName:     defaultSectionName,
+		DefaultSectionSequence: defaultSectionSequence,
+
+		MaxRetriesPerFile: maxRetriesPerFile,
+		ParallelSections:  parallelSections,
+	}
+
+	// 썸네일 미리보기 (S3/DB 불필요)
+	if previewThumbnail != "" {
+		outPath, err := PreviewThumbnail(previewThumbnail, previewOut, outputRoot, thumbnailAt)
+		if err != nil {
+			log.Fatal("썸네일 미리보기 실패:", err)
+		}
+		fmt.Println(outPath)
+		return
+	}
+
+	// 유지보수 명령 (S3 prefix 불필요)
+	if checkOrphanVideos || checkOrphanContents || fixNormalization || listSessions || mergeDuplicateVideos || dedupeVideosBySourceURL || regenerateThumbnails {
+		parser, err := NewParser(dbHost, dbPort, dbUser, dbPassword, dbName, dbSSLMode, s3Bucket, s3Region, opts)
+		if err != nil {
+			log.Fatal("Parser 초기화 실패:", err)
+		}
+		defer parser.Close()
+
+		if checkOrphanVideos {
+			if err := parser.CheckOrphanVideos(deleteOrphans, batchSize); err != nil {
+				parser.Close()
+				log.Fatal("고아 비디오 확인 실패:", err)
+			}
+		}
+		if checkOrphanContents {
+			if err := parser.CheckOrphanContents(deleteOrphans, batchSize); err != nil {
+				parser.Close()
+				log.Fatal("깨진 콘텐츠 확인 실패:", err)
+			}
+		}
+		if regenerateThumbnails {
+			ids, err := parseIDList(videoIDs)
+			if err != nil {
+				parser.Close()
+				log.Fatal("video-ids 파싱 실패:", err)
+			}
+			if err := parser.RegenerateThumbnails(ids, videoWhere, thumbnailAt, afterID, batchSize); err != nil {
+				parser.Close()
+				log.Fatal("썸네일 재생성 실패:", err)
+			}
+		}
+		if mergeDuplicateVideos {
+			if err := parser.MergeDuplicateVideos(deleteOrphans); err != nil {
+				parser.Close()
+				log.Fatal("중복 비디오 병합 실패:", err)
+			}
+		}
+		if dedupeVideosBySourceURL {
+			if err := parser.DedupeVideosBySourceURL(deleteOrphans, batchSize); err != nil {
+				parser.Close()
+				log.Fatal("source_url 중복 비디오 병합 실패:", err)
+			}
+		}
+		if listSessions {
+			if err := parser.ListSessions(filterStudentID, titleLike); err != nil {
+				parser.Close()
+				log.Fatal("세션 목록 조회 실패:", err)
+			}
+		}
+		if fixNormalization {
+			if err := parser.FixNormalization(batchSize); err != nil {
+				parser.Close()
+				log.Fatal("NFC 정규화 수정 실패:", err)
+			}
+		}
+		return
+	}
+
 	// 세션명이 비어있으면 s3Prefix를 그대로 사용
 	if sessionName == "" && s3Prefix != "" {
 		sessionName = s3Prefix
 	}
 
-	if s3Prefix == "" || studentID == 0 || dbUser == "" || dbPassword == "" || dbName == "" || s3Bucket == "" {
+	if (s3Prefix == "" && s3PrefixGlob == "") || studentID == 0 || dbUser == "" || dbPassword == "" || dbName == "" || s3Bucket == "" {
 		fmt.Println("사용법: parse_s3_content [옵션들]")
 		fmt.Println("필수 옵션:")
-		fmt.Println("  -s3-prefix='S3 폴더명' (예: '공통수학2 Day1')")
+		fmt.Println("  -s3-prefix='S3 폴더명' (예: '공통수학2 Day1') 또는 -s3-prefix-glob='패턴' (예: '공통수학2 Day*')")
 		fmt.Println("  -db-user='사용자명'")
 		fmt.Println("  -db-password='비밀번호'")
 		fmt.Println("선택 옵션:")
@@ -117,31 +508,156 @@ func main() {
 		fmt.Println("  -s3-region='리전' (기본값: ap-northeast-2)")
 		fmt.Println("  -force-replace-video (기존 비디오 강제 대체)")
 		fmt.Println("  -test-exam (연습 문제에 비디오 매핑하지 않음)")
+		fmt.Println("  -no-reuse (기존 세션/모듈/섹션을 재사용하지 않고 새로 생성)")
+		fmt.Println("  -on-existing=reuse|skip|new|prompt (기본값: prompt, 기존 세션/모듈/섹션 처리 방식)")
+		fmt.Println("  -batch-insert-contents (섹션 콘텐츠를 여러 행 INSERT로 한 번에 생성)")
+		fmt.Println("  -reconcile (개수가 같아도 섹션을 건너뛰지 않고 DB에 없거나 다른 파일만 처리)")
+		fmt.Println("  -allow-unnamed (이름 규칙에 맞지 않는 파일도 처리)")
+		fmt.Println("  -strict-numbering (섹션 파일 번호가 섞여 있거나 중복/누락되면 중단)")
+		fmt.Println("  -parallel-sections=N (기본값: 1, 동시에 처리할 섹션 수)")
+		fmt.Println("  -probe-concurrency=N (기본값: 4, 동시 ffprobe/ffmpeg/MD5 작업 수)")
+		fmt.Println("  -upload-concurrency=N (기본값: 8, 동시 S3 업로드 수)")
+		fmt.Println("  -cloudfront-rps=N (기본값: 0, CloudFront 초당 요청 수 제한. 0이면 제한 없음)")
+		fmt.Println("  -default-module-type='타입' (concept, pattern, exam. 판별 불가 모듈의 기본 타입, 기본값: unknown)")
+		fmt.Println("  -sort=sequence|key (기본값: sequence, 섹션 내 파일 정렬 방식)")
+		fmt.Println("  -probe-source=format|stream|max (기본값: format, 영상 길이로 쓸 ffprobe 값)")
+		fmt.Println("  -title-template='템플릿' (기본값: {filename}, 예: '{module} - {filename}')")
+		fmt.Println("  -save-probe-dir='디렉토리' (ffprobe JSON 출력 저장, 디버깅용)")
+		fmt.Println("  -output-root='디렉토리' (출력 파일을 쓸 수 있는 디렉토리, 기본값: 제한 없음)")
+		fmt.Println("  -stored-base-url='URL' (DB에 저장할 URL의 기본 주소, 기본값: " + cloudfrontBaseURL + ". 영상 읽기는 기존 주소 사용)")
+		fmt.Println("  -probe-via-s3 (영상 읽기에 CloudFront 대신 S3 presigned URL 사용, 저장 URL은 그대로)")
+		fmt.Println("  -transcode (.mov를 .mp4로 변환해 업로드하고 .mp4 URL 저장, 실패 시 원본 사용)")
+		fmt.Println("  -md5-cache-dir='디렉토리' (S3 키+ETag별 MD5 캐시, 재실행 시 다운로드 생략)")
+		fmt.Println("  -force-thumbnails (이미 있는 썸네일도 다시 생성)")
+		fmt.Println("  -thumbnail-bucket='버킷명' (기본값: s3-bucket, 썸네일 업로드 버킷)")
+		fmt.Println("  -thumbnail-prefix='prefix' (썸네일 키 앞에 붙일 prefix, 기본값: 영상 옆)")
+		fmt.Println("  -thumbnail-base-url='URL' (thumbnail_url 기본 URL, 기본값: stored-base-url)")
+		fmt.Println("  -default-section-name='섹션명' (기본값: 기본, 섹션 폴더 없는 모듈의 섹션 이름)")
+		fmt.Println("  -default-section-sequence=N (기본값: 0)")
+		fmt.Println("  -max-retries-per-file=N (기본값: 2, 초과 시 실패 목록으로 격리)")
+		fmt.Println("  -failed-files-out='파일 경로' (실패한 S3 키 목록 저장)")
+		fmt.Println("  -metrics-addr=':9090' (진행 상황 /progress JSON과 /healthz 제공, 기본값: 사용 안 함)")
+		fmt.Println("  -dump-failed-urls='파일 경로' (실패한 파일의 CloudFront URL 목록 저장)")
+		fmt.Println("  -id-map='파일 경로' (s3_key, video_id, content_id, content_type CSV 저장)")
+		fmt.Println("  -log-level=debug|info|warn|error (기본값: info, warn이면 파일별 진행 로그 숨김)")
+		fmt.Println("  -env-file='.env 경로' (기본값: .env)")
+		fmt.Println("DB 접속 정보, S3 버킷/리전, 기본 URL 옵션은 환경변수로도 지정 가능 (예: -db-password -> DB_PASSWORD)")
+		fmt.Println("유지보수 명령:")
+		fmt.Println("  -check-orphan-videos [-delete] [-batch-size=500] (참조되지 않는 비디오 조회/삭제)")
+		fmt.Println("  -check-orphan-contents [-delete] [-batch-size=500] (참조 대상이 삭제된 콘텐츠 조회/삭제)")
+		fmt.Println("  -regenerate-thumbnails -video-ids=1,2,3 | -video-where='조건' [-thumbnail-at=5] [-after-id=N] [-batch-size=500] (썸네일 재생성)")
+		fmt.Println("  -merge-duplicate-videos [-delete] (MD5가 같은 비디오 병합)")
+		fmt.Println("  -dedupe-videos-by-source-url [-delete] [-batch-size=500] (source_url이 같은 비디오 병합)")
+		fmt.Println("  -list-sessions [-student-id=21] [-title-like='Day1'] (세션 목록 조회)")
+		fmt.Println("  -fix-normalization [-batch-size=500] (NFD URL을 NFC로 수정)")
+		fmt.Println("  -preview-thumbnail='영상 URL' [-thumbnail-at=5] [-preview-out='파일 경로'] (썸네일 프레임을 로컬에만 추출, DB 옵션 불필요)")
 		os.Exit(1)
 	}
 
 	// Parser 초기화
-	parser, err := NewParser(dbHost, dbPort, dbUser, dbPassword, dbName, dbSSLMode, s3Bucket, s3Region, forceReplaceVideo, testExam)
+	parser, err := NewParser(dbHost, dbPort, dbUser, dbPassword, dbName, dbSSLMode, s3Bucket, s3Region, opts)
 	if err != nil {
 		log.Fatal("Parser 초기화 실패:", err)
 	}
 	defer parser.Close()
 
-	// 사전 테스트
-	if err := parser.RunPreTests(sessionName, s3Prefix); err != nil {
+	// 원격에서 긴 작업의 진행 상황을 확인할 수 있도록 (작업이 끝나면 Close에서 종료)
+	if metricsAddr != "" {
+		if err := parser.StartMetrics(metricsAddr); err != nil {
+			parser.Close()
+			log.Fatal("진행 상황 서버 시작 실패:", err)
+		}
+	}
+
+	if s3PrefixGlob != "" {
+		// 패턴에 맞는 폴더마다 폴더명을 세션명으로 처리
+		if err := parser.ProcessPrefixGlob(s3PrefixGlob, studentID, sessionSequence); err != nil {
+			parser.Close()
+			log.Fatal("세션 처리 실패:", err)
+		}
+	} else {
+		// 사전 테스트
+		if err := parser.RunPreTests(sessionName, s3Prefix); err != nil {
+			parser.Close()
+			log.Fatal("사전 테스트 실패:", err)
+		}
+
+		// 메인 처리
+		if err := parser.ProcessSession(sessionName, s3Prefix, studentID, sessionSequence); err != nil {
+			log.Fatal("세션 처리 실패:", err)
+		}
+	}
+
+	// 격리된 파일 보고 (재실행 대상)
+	if err := parser.ReportFailedFiles(failedFilesOut); err != nil {
 		parser.Close()
-		log.Fatal("사전 테스트 실패:", err)
+		log.Fatal("실패 목록 저장 실패:", err)
+	}
+	if dumpFailedURLs != "" {
+		if err := parser.DumpFailedURLs(dumpFailedURLs); err != nil {
+			parser.Close()
+			log.Fatal("실패 URL 목록 저장 실패:", err)
+		}
 	}
 
-	// 메인 처리
-	if err := parser.ProcessSession(sessionName, s3Prefix, studentID, sessionSequence); err != nil {
-		log.Fatal("세션 처리 실패:", err)
+	// S3 키 ↔ 생성된 ID 매핑 저장
+	if idMapOut != "" {
+		if err := parser.WriteIDMap(idMapOut); err != nil {
+			parser.Close()
+			log.Fatal("ID 매핑 저장 실패:", err)
+		}
 	}
 
 	log.Println("✅ S3 콘텐츠 파싱 완료!")
 }
 
-func NewParser(dbHost string, dbPort int, dbUser, dbPassword, dbName, dbSSLMode, bucketName, region string, forceReplaceVideo bool, testExam bool) (*Parser, error) {
+func NewParser(dbHost string, dbPort int, dbUser, dbPassword, dbName, dbSSLMode, bucketName, region string, opts ParserOptions) (*Parser, error) {
+	if opts.ProbeConcurrency < 1 || opts.UploadConcurrency < 1 {
+		return nil, fmt.Errorf("probe-concurrency와 upload-concurrency는 1 이상이어야 합니다")
+	}
+	if opts.CloudFrontRPS < 0 {
+		return nil, fmt.Errorf("cloudfront-rps는 0 이상이어야 합니다")
+	}
+	if opts.ParallelSections < 1 {
+		return nil, fmt.Errorf("parallel-sections는 1 이상이어야 합니다")
+	}
+	if opts.MaxRetriesPerFile < 0 {
+		return nil, fmt.Errorf("max-retries-per-file은 0 이상이어야 합니다")
+	}
+	switch opts.DefaultModuleType {
+	case "", "concept", "pattern", "exam":
+	default:
+		return nil, fmt.Errorf("default-module-type은 concept, pattern, exam 중 하나여야 합니다: %s", opts.DefaultModuleType)
+	}
+	if opts.SortMode != "sequence" && opts.SortMode != "key" {
+		return nil, fmt.Errorf("sort는 sequence 또는 key여야 합니다: %s", opts.SortMode)
+	}
+	if opts.ProbeSource != "format" && opts.ProbeSource != "stream" && opts.ProbeSource != "max" {
+		return nil, fmt.Errorf("probe-source는 format, stream, max 중 하나여야 합니다: %s", opts.ProbeSource)
+	}
+	onExisting := opts.OnExisting
+	if onExisting == "" {
+		onExisting = "prompt"
+	}
+	if onExisting != "reuse" && onExisting != "skip" && onExisting != "new" && onExisting != "prompt" {
+		return nil, fmt.Errorf("on-existing은 reuse, skip, new, prompt 중 하나여야 합니다: %s", onExisting)
+	}
+	if opts.NoReuse {
+		if onExisting != "prompt" && onExisting != "new" {
+			return nil, fmt.Errorf("no-reuse는 on-existing=%s와 함께 사용할 수 없습니다", onExisting)
+		}
+		onExisting = "new"
+	}
+	if opts.BatchContents && opts.ForceReplaceVideo {
+		return nil, fmt.Errorf("batch-insert-contents는 force-replace-video와 함께 사용할 수 없습니다")
+	}
+	if opts.Reconcile && opts.ForceReplaceVideo {
+		return nil, fmt.Errorf("reconcile은 force-replace-video와 함께 사용할 수 없습니다")
+	}
+	if opts.Reconcile && opts.BatchContents {
+		return nil, fmt.Errorf("reconcile은 batch-insert-contents와 함께 사용할 수 없습니다")
+	}
+
 	// 데이터베이스 연결
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		dbHost, dbPort, dbUser, dbPassword, dbName, dbSSLMode)
@@ -158,23 +674,194 @@ func NewParser(dbHost string, dbPort int, dbUser, dbPassword, dbName, dbSSLMode,
 		return nil, fmt.Errorf("AWS 설정 실패 -> %w", err)
 	}
 
+	thumbnailBucket := opts.ThumbnailBucket
+	if thumbnailBucket == "" {
+		thumbnailBucket = bucketName
+	}
+	storedBaseURL := strings.TrimSuffix(opts.StoredBaseURL, "/")
+	if storedBaseURL == "" {
+		storedBaseURL = cloudfrontBaseURL
+	}
+	thumbnailBaseURL := strings.TrimSuffix(opts.ThumbnailBaseURL, "/")
+	if thumbnailBaseURL == "" {
+		thumbnailBaseURL = storedBaseURL
+	}
+
+	var cache *md5cache.Cache
+	if opts.MD5CacheDir != "" {
+		cache, err = md5cache.Open(opts.MD5CacheDir)
+		if err != nil {
+			return nil, fmt.Errorf("MD5 캐시 로드 실패 -> %w", err)
+		}
+		log.Printf("MD5 캐시 로드: %d개 항목 (%s)", cache.Len(), opts.MD5CacheDir)
+	}
+
+	// 티커는 Close에서 멈추므로 더 이상 실패할 일이 없을 때 만듦
+	var cdnLimiter *rateLimiter
+	if opts.CloudFrontRPS > 0 {
+		cdnLimiter = newRateLimiter(opts.CloudFrontRPS)
+	}
+
+	s3Client := s3.NewFromConfig(awsCfg)
+
 	return &Parser{
 		db:                db,
-		s3Client:          s3.NewFromConfig(awsCfg),
+		s3Client:          s3Client,
+		presignClient:     s3.NewPresignClient(s3Client),
 		ctx:               context.Background(),
 		bucketName:        bucketName,
 		region:            region,
-		forceReplaceVideo: forceReplaceVideo,
-		testExam:          testExam,
+		forceReplaceVideo: opts.ForceReplaceVideo,
+		testExam:          opts.TestExam,
+		onExisting:        onExisting,
+		allowUnnamed:      opts.AllowUnnamed,
+		batchContents:     opts.BatchContents,
+		reconcile:         opts.Reconcile,
+		strictNumbering:   opts.StrictNumbering,
+		defaultModuleType: opts.DefaultModuleType,
+		sortMode:          opts.SortMode,
+		probeSource:       opts.ProbeSource,
+		titleTemplate:     opts.TitleTemplate,
+		saveProbeDir:      opts.SaveProbeDir,
+		outputRoot:        opts.OutputRoot,
+		md5Cache:          cache,
+
+		storedBaseURL:    storedBaseURL,
+		probeViaS3:       opts.ProbeViaS3,
+		transcode:        opts.Transcode,
+		thumbnailBucket:  thumbnailBucket,
+		forceThumbnails:  opts.ForceThumbnails,
+		thumbnailPrefix:  strings.Trim(opts.ThumbnailPrefix, "/"),
+		thumbnailBaseURL: thumbnailBaseURL,
+
+		defaultSectionName:     opts.DefaultSectionName,
+		defaultSectionSequence: opts.DefaultSectionSequence,
+		maxRetriesPerFile:      opts.MaxRetriesPerFile,
+		fileRetryDelay:         fileRetryDelay,
+		parallelSections:       opts.ParallelSections,
+
+		probeSem:     make(chan struct{}, opts.ProbeConcurrency),
+		uploadSem:    make(chan struct{}, opts.UploadConcurrency),
+		transcodeSem: make(chan struct{}, transcodeConcurrency),
+
+		cdnLimiter: cdnLimiter,
+		progress:   newProgressTracker(),
 	}, nil
 }
 
+// sectionRunner 섹션 콘텐츠 처리를 최대 limit개까지 동시에 실행
+// limit이 1이면 Go에서 바로 실행하므로 기존 순차 처리와 같음
+type sectionRunner struct {
+	limit int
+	sem   chan struct{}
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	err   error
+}
+
+func newSectionRunner(limit int) *sectionRunner {
+	return &sectionRunner{limit: limit, sem: make(chan struct{}, limit)}
+}
+
+// Go fn을 실행(동시 실행 시 슬롯이 날 때까지 대기). 지금까지 발생한 첫 에러를 반환하므로 호출자는 에러가 있으면 중단
+func (r *sectionRunner) Go(fn func() error) error {
+	if r.limit <= 1 {
+		return fn()
+	}
+
+	r.sem <- struct{}{}
+	r.wg.Add(1)
+	go func() {
+		defer func() {
+			<-r.sem
+			r.wg.Done()
+		}()
+		if err := fn(); err != nil {
+			r.mu.Lock()
+			if r.err == nil {
+				r.err = err
+			}
+			r.mu.Unlock()
+		}
+	}()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Wait 실행 중인 작업을 모두 기다리고 첫 에러를 반환
+func (r *sectionRunner) Wait() error {
+	r.wg.Wait()
+	return r.err
+}
+
+// acquireProbe CloudFront 읽기 작업 슬롯 획득 후 요청 토큰 대기. 반환된 함수로 해제
+func (p *Parser) acquireProbe() func() {
+	p.probeSem <- struct{}{}
+	p.cdnLimiter.Wait()
+	return func() { <-p.probeSem }
+}
+
+// acquireTranscode mp4 변환 슬롯 획득 후 CloudFront 요청 토큰 대기. 반환된 함수로 해제
+// 변환은 몇 분씩 걸리므로 probeSem을 잡고 있으면 다른 섹션의 ffprobe/MD5가 모두 멈춤
+func (p *Parser) acquireTranscode() func() {
+	p.transcodeSem <- struct{}{}
+	p.cdnLimiter.Wait()
+	return func() { <-p.transcodeSem }
+}
+
+// acquireUpload S3 업로드 슬롯 획득. 반환된 함수로 해제
+func (p *Parser) acquireUpload() func() {
+	p.uploadSem <- struct{}{}
+	return func() { <-p.uploadSem }
+}
+
 func (p *Parser) Close() {
+	p.stopMetrics()
+	p.cdnLimiter.Stop()
+
+	p.stmtMu.Lock()
+	for query, stmt := range p.stmts {
+		_ = stmt.Close()
+		delete(p.stmts, query)
+	}
+	p.stmtMu.Unlock()
+
 	if p.db != nil {
 		_ = p.db.Close()
 	}
 }
 
+// prepared 쿼리를 처음 쓸 때 한 번만 준비하고 이후에는 재사용
+// 섹션을 동시에 처리해도 *sql.Stmt는 여러 고루틴에서 함께 쓸 수 있음
+func (p *Parser) prepared(query string) (*sql.Stmt, error) {
+	p.stmtMu.Lock()
+	defer p.stmtMu.Unlock()
+
+	if stmt, ok := p.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := p.db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	if p.stmts == nil {
+		p.stmts = make(map[string]*sql.Stmt)
+	}
+	p.stmts[query] = stmt
+	return stmt, nil
+}
+
+// queryRowPrepared prepared statement로 QueryRow. 준비에 실패하면 기존처럼 일반 QueryRow로 실행
+func (p *Parser) queryRowPrepared(query string, args ...any) *sql.Row {
+	stmt, err := p.prepared(query)
+	if err != nil {
+		return p.db.QueryRow(query, args...)
+	}
+	return stmt.QueryRow(args...)
+}
+
 func (p *Parser) RunPreTests(sessionName, s3Prefix string) error {
 	fmt.Println("==============================================")
 	fmt.Println("       S3 콘텐츠 파싱 스크립트 사전 테스트")
@@ -200,6 +887,10 @@ func (p *Parser) RunPreTests(sessionName, s3Prefix string) error {
 		return fmt.Errorf("PostgreSQL 연결 실패 -> %w", err)
 	}
 	fmt.Printf("✓ PostgreSQL 연결 성공\n")
+	if err := p.VerifySchema(); err != nil {
+		return err
+	}
+	fmt.Println("✓ DB 스키마 확인 완료")
 	fmt.Println()
 
 	// 3. S3 연결 확인
@@ -234,8 +925,8 @@ func (p *Parser) RunPreTests(sessionName, s3Prefix string) error {
 	}
 	fmt.Println()
 
-	// 5. CloudFront 테스트
-	fmt.Println("=== CloudFront 접근 테스트 ===")
+	// 5. CloudFront(-probe-via-s3이면 S3 presigned URL) 테스트
+	fmt.Println("=== 영상 접근 테스트 ===")
 	files, err := p.GetFilesInSection(s3Prefix, modules[0], "")
 	if err != nil || len(files) == 0 {
 		// 첫 번째 섹션 찾기
@@ -246,7 +937,11 @@ func (p *Parser) RunPreTests(sessionName, s3Prefix string) error {
 	}
 
 	if len(files) > 0 {
-		testURL := fmt.Sprintf("%s/%s", cloudfrontBaseURL, urlPathEncode(files[0]))
+		testURL, err := p.readURL(files[0])
+		if err != nil {
+			return err
+		}
+		testURL = p.resolveVideoURL(testURL)
 		fmt.Printf("테스트 URL: %s\n", testURL)
 
 		duration, err := getVideoDuration(testURL)
@@ -271,15 +966,76 @@ func (p *Parser) RunPreTests(sessionName, s3Prefix string) error {
 	return nil
 }
 
+// requiredColumns 세션 생성에서 읽고 쓰는 테이블과 컬럼
+var requiredColumns = map[string][]string{
+	"learning_sessions": {"id", "student_id", "status", "sequence", "title", "date", "deleted_at"},
+	"learning_modules":  {"id", "title", "type", "sequence", "session_id", "deleted_at"},
+	"learning_sections": {"id", "title", "sequence", "module_id", "deleted_at"},
+	"learning_contents": {"id", "title", "content_type", "lecture_id", "exercise_id", "required_exercise_group_id", "exercise_type", "sequence", "section_id", "user_id", "deleted_at"},
+	"videos":            {"id", "uuid", "title", "source_url", "thumbnail_url", "max_progress", "md5_hash", "deleted_at"},
+	"lectures":          {"id", "title", "category_id", "lecture_video_id"},
+	"exercises":         {"id", "ref_id", "solution_video_id"},
+}
+
+// VerifySchema information_schema에서 requiredColumns가 모두 있는지 확인
+// 오래된 스키마에서 처리 도중 SQL 에러로 멈추지 않도록, 없는 컬럼을 "videos.md5_hash" 형식으로 모두 나열해 에러 반환
+func (p *Parser) VerifySchema() error {
+	rows, err := p.db.Query(`SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = current_schema()`)
+	if err != nil {
+		return fmt.Errorf("DB 스키마 조회 실패 -> %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return fmt.Errorf("DB 스키마 스캔 실패 -> %w", err)
+		}
+		existing[table+"."+column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("DB 스키마 조회 실패 -> %w", err)
+	}
+
+	tables := make([]string, 0, len(requiredColumns))
+	for table := range requiredColumns {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var missing []string
+	for _, table := range tables {
+		for _, column := range requiredColumns[table] {
+			if !existing[table+"."+column] {
+				missing = append(missing, table+"."+column)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("DB 스키마에 없는 컬럼: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 func (p *Parser) ProcessSession(sessionName, s3Prefix string, studentID, sessionSequence int) error {
-	log.Printf("S3 콘텐츠 파싱 시작: %s (student_id: %d)", sessionName, studentID)
+	loglevel.Infof("S3 콘텐츠 파싱 시작: %s (student_id: %d)", sessionName, studentID)
+	p.progress.update(func(t *progressTracker) { t.Session = sessionName })
+
+	// 0. 섹션별 파일 번호 확인 (DB에 쓰기 전에)
+	if err := p.CheckNumbering(s3Prefix); err != nil {
+		return err
+	}
 
 	// 1. 세션 생성
 	sessionID, err := p.createSession(sessionName, studentID, sessionSequence)
+	if errors.Is(err, errSkipExisting) {
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("세션 생성 실패 -> %w", err)
 	}
-	log.Printf("세션 생성 완료: ID %d", sessionID)
+	loglevel.Infof("세션 생성 완료: ID %d", sessionID)
 
 	// 2. 모듈 처리
 	modules, err := p.GetModules(s3Prefix)
@@ -287,41 +1043,279 @@ func (p *Parser) ProcessSession(sessionName, s3Prefix string, studentID, session
 		return fmt.Errorf("모듈 목록 조회 실패 -> %w", err)
 	}
 
+	// 섹션 콘텐츠 처리는 -parallel-sections만큼 동시에 실행 (생성은 이 루프에서 순차)
+	runner := newSectionRunner(p.parallelSections)
+
 	for i, moduleName := range modules {
 		moduleType := p.getModuleType(moduleName)
 		moduleSeq := extractSequenceWithIndex(moduleName, i)
-		log.Printf("모듈 처리 시작: %s (type: %s, seq: %d)", moduleName, moduleType, moduleSeq)
+		loglevel.Infof("모듈 처리 시작: %s (type: %s, seq: %d)", moduleName, moduleType, moduleSeq)
+		p.progress.update(func(t *progressTracker) {
+			t.Module = moduleName
+			t.ModulesStarted++
+		})
 		moduleID, err := p.createModule(moduleName, sessionID, moduleSeq, moduleType)
+		if errors.Is(err, errSkipExisting) {
+			continue
+		}
 		if err != nil {
+			_ = runner.Wait()
 			return fmt.Errorf("모듈 생성 실패 -> %w", err)
 		}
-		log.Printf("모듈 생성 완료: ID %d", moduleID)
+		loglevel.Infof("모듈 생성 완료: ID %d", moduleID)
 
 		// 3. 섹션 처리
 		sections, err := p.GetSections(s3Prefix, moduleName)
 		if err != nil {
+			_ = runner.Wait()
 			return fmt.Errorf("섹션 목록 조회 실패 -> %w", err)
 		}
 
+		// 섹션 폴더 없이 모듈 바로 아래 파일만 있는 경우 기본 섹션으로 처리
+		if len(sections) == 0 {
+			if err := p.processLooseFiles(runner, s3Prefix, moduleName, moduleID, studentID, moduleType); err != nil {
+				_ = runner.Wait()
+				return err
+			}
+			continue
+		}
+
 		for j, sectionName := range sections {
 			sectionID, err := p.createSectionWithIndex(sectionName, moduleID, j)
+			if errors.Is(err, errSkipExisting) {
+				continue
+			}
 			if err != nil {
+				_ = runner.Wait()
 				return fmt.Errorf("섹션 생성 실패 -> %w", err)
 			}
-			log.Printf("섹션 생성 완료: ID %d", sectionID)
+			loglevel.Infof("섹션 생성 완료: ID %d", sectionID)
 
 			// 4. 콘텐츠 처리
-			log.Printf("콘텐츠 처리 시작: section_id %d", sectionID)
-			if err := p.processSectionContents(s3Prefix, moduleName, sectionName, sectionID, studentID, moduleType); err != nil {
-				return fmt.Errorf("콘텐츠 처리 실패 -> %w", err)
+			if err := p.runSectionContents(runner, s3Prefix, moduleName, sectionName, sectionID, studentID, moduleType); err != nil {
+				_ = runner.Wait()
+				return err
+			}
+		}
+	}
+
+	if err := runner.Wait(); err != nil {
+		return err
+	}
+	p.progress.update(func(t *progressTracker) { t.SessionsDone++ })
+	return nil
+}
+
+// runSectionContents processSectionContents를 runner로 실행
+func (p *Parser) runSectionContents(runner *sectionRunner, s3Prefix, moduleName, sectionName string, sectionID int64, studentID int, moduleType string) error {
+	return runner.Go(func() error {
+		loglevel.Infof("콘텐츠 처리 시작: section_id %d", sectionID)
+		if err := p.processSectionContents(s3Prefix, moduleName, sectionName, sectionID, studentID, moduleType); err != nil {
+			return fmt.Errorf("콘텐츠 처리 실패 -> %w", err)
+		}
+		loglevel.Infof("콘텐츠 처리 완료: section_id %d", sectionID)
+		p.progress.update(func(t *progressTracker) { t.SectionsDone++ })
+		return nil
+	})
+}
+
+// CheckNumbering 모든 섹션의 파일 번호(N_)가 섞여 있거나 중복/누락되었는지 확인해 경고
+// -strict-numbering이면 문제가 있는 섹션이 하나라도 있을 때 에러를 반환
+func (p *Parser) CheckNumbering(s3Prefix string) error {
+	modules, err := p.GetModules(s3Prefix)
+	if err != nil {
+		return fmt.Errorf("모듈 목록 조회 실패 -> %w", err)
+	}
+
+	var badSections []string
+	for _, moduleName := range modules {
+		sections, err := p.GetSections(s3Prefix, moduleName)
+		if err != nil {
+			return fmt.Errorf("섹션 목록 조회 실패 -> %w", err)
+		}
+		if len(sections) == 0 {
+			sections = []string{""} // 섹션 폴더 없이 모듈 바로 아래 있는 파일
+		}
+
+		for _, sectionName := range sections {
+			files, err := p.GetFilesInSection(s3Prefix, moduleName, sectionName)
+			if err != nil {
+				return fmt.Errorf("S3 파일 목록 조회 실패 -> %w", err)
+			}
+			names := make([]string, 0, len(files))
+			for _, file := range files {
+				name := path.Base(file)
+				if p.allowUnnamed || classifyFile(name) != "" {
+					names = append(names, name)
+				}
+			}
+
+			issues := checkSectionNumbering(names)
+			if len(issues) == 0 {
+				continue
+			}
+			section := path.Join(moduleName, sectionName)
+			badSections = append(badSections, section)
+			for _, issue := range issues {
+				loglevel.Warnf("⚠️  파일 번호 문제 (%s): %s", section, issue)
+			}
+		}
+	}
+
+	if len(badSections) > 0 && p.strictNumbering {
+		return fmt.Errorf("파일 번호 문제가 있는 섹션 %d개 (-strict-numbering): %s", len(badSections), strings.Join(badSections, ", "))
+	}
+	return nil
+}
+
+var sequencePrefixRe = regexp.MustCompile(`^(\d+)_`)
+
+// checkSectionNumbering 한 섹션의 파일명 번호 검사
+// 번호 있는 파일과 없는 파일이 섞였거나(없는 파일은 sequence 0이 됨), 같은 번호가 여러 파일에 있거나, 중간 번호가 빠진 경우를 문제로 보고
+func checkSectionNumbering(filenames []string) []string {
+	byNumber := make(map[int][]string)
+	var unnumbered []string
+	for _, name := range filenames {
+		matches := sequencePrefixRe.FindStringSubmatch(name)
+		if matches == nil {
+			unnumbered = append(unnumbered, name)
+			continue
+		}
+		n, _ := strconv.Atoi(matches[1])
+		byNumber[n] = append(byNumber[n], name)
+	}
+
+	var issues []string
+	if len(unnumbered) > 0 && len(byNumber) > 0 {
+		issues = append(issues, fmt.Sprintf("번호 없는 파일이 섞여 있음: %s", strings.Join(unnumbered, ", ")))
+	}
+
+	numbers := make([]int, 0, len(byNumber))
+	for n := range byNumber {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	for i, n := range numbers {
+		if len(byNumber[n]) > 1 {
+			issues = append(issues, fmt.Sprintf("번호 %d 중복: %s", n, strings.Join(byNumber[n], ", ")))
+		}
+		if i > 0 && n > numbers[i-1]+1 {
+			missing := strconv.Itoa(numbers[i-1] + 1)
+			if n-1 > numbers[i-1]+1 {
+				missing += "~" + strconv.Itoa(n-1)
 			}
-			log.Printf("콘텐츠 처리 완료: section_id %d", sectionID)
+			issues = append(issues, fmt.Sprintf("번호 %s 누락 (%s 다음)", missing, byNumber[numbers[i-1]][0]))
+		}
+	}
+	return issues
+}
+
+// processLooseFiles 모듈 바로 아래 있는 파일들을 기본 섹션을 만들어 처리
+func (p *Parser) processLooseFiles(runner *sectionRunner, s3Prefix, moduleName string, moduleID int64, studentID int, moduleType string) error {
+	files, err := p.GetFilesInSection(s3Prefix, moduleName, "")
+	if err != nil {
+		return fmt.Errorf("모듈 파일 목록 조회 실패 -> %w", err)
+	}
+	if len(files) == 0 {
+		loglevel.Infof("섹션과 파일이 없는 모듈, 스킵: %s", moduleName)
+		return nil
+	}
+
+	loglevel.Infof("섹션 폴더 없이 파일 %d개 발견, 기본 섹션 사용: %s (sequence: %d)", len(files), p.defaultSectionName, p.defaultSectionSequence)
+	sectionID, err := p.createSectionWithIndex(p.defaultSectionName, moduleID, p.defaultSectionSequence)
+	if errors.Is(err, errSkipExisting) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("기본 섹션 생성 실패 -> %w", err)
+	}
+	loglevel.Infof("섹션 생성 완료: ID %d", sectionID)
+
+	return p.runSectionContents(runner, s3Prefix, moduleName, "", sectionID, studentID, moduleType)
+}
+
+// ProcessPrefixGlob lectures/ 아래 pattern에 맞는 폴더를 각각 세션으로 처리
+// 사전 테스트(확인 프롬프트 포함)는 첫 폴더로 한 번만 실행하고, 실패한 세션이 있어도 나머지를 계속 처리
+func (p *Parser) ProcessPrefixGlob(pattern string, studentID, sessionSequence int) error {
+	names, err := p.listSessionPrefixes()
+	if err != nil {
+		return fmt.Errorf("S3 폴더 목록 조회 실패 -> %w", err)
+	}
+	prefixes, err := matchPrefixes(names, pattern)
+	if err != nil {
+		return err
+	}
+	if len(prefixes) == 0 {
+		return fmt.Errorf("패턴에 맞는 S3 폴더가 없습니다: %s", pattern)
+	}
+
+	fmt.Printf("패턴 %q에 맞는 폴더 %d개:\n", pattern, len(prefixes))
+	for _, prefix := range prefixes {
+		fmt.Printf("  - %s\n", prefix)
+	}
+	fmt.Println()
+
+	if err := p.RunPreTests(prefixes[0], prefixes[0]); err != nil {
+		return fmt.Errorf("사전 테스트 실패 -> %w", err)
+	}
+
+	var failed []string
+	for _, prefix := range prefixes {
+		if err := p.ProcessSession(prefix, prefix, studentID, sessionSequence); err != nil {
+			loglevel.Warnf("세션 처리 실패: %s -> %v", prefix, err)
+			failed = append(failed, prefix)
 		}
 	}
 
+	log.Printf("세션 처리 결과: %d개 성공, %d개 실패", len(prefixes)-len(failed), len(failed))
+	if len(failed) > 0 {
+		return fmt.Errorf("실패한 세션: %s", strings.Join(failed, ", "))
+	}
 	return nil
 }
 
+// listSessionPrefixes lectures/ 바로 아래 폴더명 목록
+func (p *Parser) listSessionPrefixes() ([]string, error) {
+	var names []string
+	paginator := s3.NewListObjectsV2Paginator(p.s3Client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(p.bucketName),
+		Prefix:    aws.String("lectures/"),
+		Delimiter: aws.String("/"),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(p.ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, prefix := range page.CommonPrefixes {
+			name := strings.TrimSuffix(strings.TrimPrefix(*prefix.Prefix, "lectures/"), "/")
+			if trimName(name) != "" && !strings.HasPrefix(name, ".") {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// matchPrefixes path.Match 패턴에 맞는 폴더명을 정렬해 반환. NFD로 업로드된 폴더도 맞도록 NFC로 비교
+func matchPrefixes(names []string, pattern string) ([]string, error) {
+	pattern = norm.NFC.String(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("잘못된 패턴: %s -> %w", pattern, err)
+	}
+
+	var matched []string
+	for _, name := range names {
+		if ok, _ := path.Match(pattern, norm.NFC.String(name)); ok {
+			matched = append(matched, name)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return norm.NFC.String(matched[i]) < norm.NFC.String(matched[j])
+	})
+	return matched, nil
+}
+
 func (p *Parser) GetModules(s3Prefix string) ([]string, error) {
 	prefix := fmt.Sprintf("lectures/%s/", s3Prefix)
 
@@ -341,6 +1335,11 @@ func (p *Parser) GetModules(s3Prefix string) ([]string, error) {
 		parts := strings.Split(strings.TrimSuffix(modulePath, "/"), "/")
 		if len(parts) >= 3 {
 			moduleName := parts[2]
+			// 공백/보이지 않는 문자만 있는 폴더 제외
+			if trimName(moduleName) == "" {
+				loglevel.Warnf("⚠️  이름이 비어있는 모듈 폴더 무시: %q", modulePath)
+				continue
+			}
 			// .으로 시작하는 폴더 제외
 			if !strings.HasPrefix(moduleName, ".") {
 				modules = append(modules, moduleName)
@@ -371,6 +1370,11 @@ func (p *Parser) GetSections(s3Prefix, moduleName string) ([]string, error) {
 		parts := strings.Split(strings.TrimSuffix(sectionPath, "/"), "/")
 		if len(parts) >= 4 {
 			sectionName := parts[3]
+			// 공백/보이지 않는 문자만 있는 폴더 제외
+			if trimName(sectionName) == "" {
+				loglevel.Warnf("⚠️  이름이 비어있는 섹션 폴더 무시: %q", sectionPath)
+				continue
+			}
 			// .으로 시작하는 폴더 제외
 			if !strings.HasPrefix(sectionName, ".") {
 				sections = append(sections, sectionName)
@@ -382,18 +1386,36 @@ func (p *Parser) GetSections(s3Prefix, moduleName string) ([]string, error) {
 	return sections, nil
 }
 
+// GetFilesInSection 섹션의 영상 파일 목록 조회
+// sectionName이 비어있으면 섹션 폴더를 제외하고 모듈 바로 아래 있는 파일만 조회
 func (p *Parser) GetFilesInSection(s3Prefix, moduleName, sectionName string) ([]string, error) {
-	prefix := fmt.Sprintf("lectures/%s/%s/%s/", s3Prefix, moduleName, sectionName)
-
-	result, err := p.s3Client.ListObjectsV2(p.ctx, &s3.ListObjectsV2Input{
+	input := &s3.ListObjectsV2Input{
 		Bucket: aws.String(p.bucketName),
-		Prefix: aws.String(prefix),
-	})
-	if err != nil {
-		return nil, err
+		Prefix: aws.String(fmt.Sprintf("lectures/%s/%s/%s/", s3Prefix, moduleName, sectionName)),
+	}
+	if sectionName == "" {
+		input.Prefix = aws.String(fmt.Sprintf("lectures/%s/%s/", s3Prefix, moduleName))
+		input.Delimiter = aws.String("/")
+	}
+
+	result, err := p.s3Client.ListObjectsV2(p.ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// -transcode로 .mov 옆에 만든 .mp4는 원본과 같은 영상이므로 제외 (-transcode가 없으면 같은 이름의 .mp4도 그대로 처리)
+	movStems := make(map[string]bool)
+	if p.transcode {
+		for _, obj := range result.Contents {
+			if key, ok := transcodedKey(norm.NFC.String(*obj.Key)); ok {
+				movStems[key] = true
+			}
+		}
 	}
 
 	var files []string
+	// NFC 정규화 키 -> files 인덱스 (NFD/NFC로 중복 업로드된 객체를 하나로 취급)
+	seen := make(map[string]int)
 	for _, obj := range result.Contents {
 		key := *obj.Key
 		filename := path.Base(key)
@@ -401,8 +1423,25 @@ func (p *Parser) GetFilesInSection(s3Prefix, moduleName, sectionName string) ([]
 		// .으로 시작하는 파일과 썸네일 제외
 		if !strings.HasPrefix(filename, ".") &&
 			!strings.Contains(filename, "_thumbnail") &&
+			!movStems[norm.NFC.String(key)] &&
 			(strings.HasSuffix(filename, ".mov") || strings.HasSuffix(filename, ".mp4")) {
 
+			// s3-uploader가 중간에 끊기면 0바이트 객체가 남음 (길이 0인 비디오가 생성되지 않도록 제외)
+			if aws.ToInt64(obj.Size) == 0 {
+				loglevel.Warnf("⚠️  크기가 0인 객체 스킵 (업로드 중단 의심): %s", key)
+				continue
+			}
+
+			nfcKey := norm.NFC.String(key)
+			if idx, ok := seen[nfcKey]; ok {
+				// s3-uploader가 올리는 NFC 키를 우선 사용
+				if key == nfcKey {
+					files[idx] = key
+				}
+				loglevel.Infof("NFC/NFD 중복 객체 무시: %s", key)
+				continue
+			}
+			seen[nfcKey] = len(files)
 			files = append(files, key)
 		}
 	}
@@ -411,6 +1450,27 @@ func (p *Parser) GetFilesInSection(s3Prefix, moduleName, sectionName string) ([]
 	return files, nil
 }
 
+// objectExists S3 객체 존재 여부 확인
+func (p *Parser) objectExists(key string) (bool, error) {
+	return p.objectExistsIn(p.bucketName, key)
+}
+
+// objectExistsIn bucket의 S3 객체 존재 여부 확인
+func (p *Parser) objectExistsIn(bucket, key string) (bool, error) {
+	_, err := p.s3Client.HeadObject(p.ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 // 데이터베이스 생성 함수들
 func (p *Parser) createSession(name string, studentID, sequence int) (int64, error) {
 	// 같은 타이틀의 세션이 이미 있는지 확인 (삭제되지 않은 것만)
@@ -418,17 +1478,15 @@ func (p *Parser) createSession(name string, studentID, sequence int) (int64, err
 	checkQuery := `SELECT id FROM learning_sessions WHERE student_id = $1 AND title = $2 AND deleted_at IS NULL`
 	err := p.db.QueryRow(checkQuery, studentID, name).Scan(&existingID)
 
-	// 이미 존재하는 경우 사용자에게 확인
+	// 이미 존재하는 경우 -on-existing에 따라 처리 (prompt이면 사용자에게 확인)
 	if err == nil {
-		fmt.Printf("⚠️  동일한 타이틀의 세션이 이미 존재합니다 (ID: %d, Title: %s)\n", existingID, name)
-		fmt.Print("기존 세션을 사용하시겠습니까? [y/N]: ")
-		var response string
-		_, _ = fmt.Scanln(&response)
-		if response == "y" || response == "Y" {
-			log.Printf("기존 세션 사용: ID %d (title: %s)", existingID, name)
+		reuse, err := p.resolveExisting("세션", existingID, name, true)
+		if err != nil {
+			return 0, err
+		}
+		if reuse {
+			loglevel.Infof("기존 세션 사용: ID %d (title: %s)", existingID, name)
 			return existingID, nil
-		} else {
-			return 0, fmt.Errorf("작업이 취소되었습니다")
 		}
 	}
 
@@ -444,36 +1502,58 @@ func (p *Parser) createSession(name string, studentID, sequence int) (int64, err
 		return 0, err
 	}
 
-	log.Printf("새 세션 생성: ID %d (title: %s)", id, name)
+	loglevel.Infof("새 세션 생성: ID %d (title: %s)", id, name)
 	return id, err
 }
 
-func (p *Parser) createModule(name string, sessionID int64, sequence int, moduleType string) (int64, error) {
-	// 모듈명에서 sequence 번호와 타입 제거 (예: "0_개념_점과 좌표" -> "점과 좌표")
-	baseName := name
-
-	// 먼저 앞의 숫자_ 부분 제거
-	re := regexp.MustCompile(`^\d+_`)
-	baseName = re.ReplaceAllString(baseName, "")
-
-	// 그다음 타입 제거
-	if strings.Contains(baseName, "개념_") {
-		baseName = strings.Replace(baseName, "개념_", "", 1)
-	} else if strings.Contains(baseName, "유형_") {
-		baseName = strings.Replace(baseName, "유형_", "", 1)
-	} else if strings.Contains(baseName, "시험_") {
-		baseName = strings.Replace(baseName, "시험_", "", 1)
+// errSkipExisting -on-existing=skip으로 기존 세션/모듈/섹션을 건너뛸 때 create 함수들이 반환
+var errSkipExisting = errors.New("기존 항목 스킵")
+
+// resolveExisting 같은 세션/모듈/섹션이 이미 있을 때 -on-existing에 따라 재사용 여부 결정
+// skip이면 errSkipExisting, new이면 false를 반환. prompt는 interactive(세션)일 때만 사용자에게 묻고 나머지는 재사용
+func (p *Parser) resolveExisting(kind string, existingID int64, title string, interactive bool) (bool, error) {
+	switch p.onExisting {
+	case "skip":
+		loglevel.Infof("-on-existing=skip: 기존 %s 스킵: ID %d (title: %s)", kind, existingID, title)
+		return false, errSkipExisting
+	case "new":
+		loglevel.Infof("-on-existing=new: 같은 타이틀의 기존 %s(ID %d)을 무시하고 새로 생성", kind, existingID)
+		return false, nil
+	case "prompt":
+		if !interactive {
+			return true, nil
+		}
+		fmt.Printf("⚠️  동일한 타이틀의 %s이 이미 존재합니다 (ID: %d, Title: %s)\n", kind, existingID, title)
+		fmt.Printf("기존 %s을 사용하시겠습니까? [y/N]: ", kind)
+		var response string
+		_, _ = fmt.Scanln(&response)
+		if response == "y" || response == "Y" {
+			return true, nil
+		}
+		return false, fmt.Errorf("작업이 취소되었습니다")
+	default: // reuse
+		return true, nil
 	}
+}
+
+func (p *Parser) createModule(name string, sessionID int64, sequence int, moduleType string) (int64, error) {
+	baseName := trimName(extractModuleTitle(name))
 
 	// 같은 title + sequence 조합의 모듈이 이미 있는지 확인 (삭제되지 않은 것만)
 	var existingID int64
 	checkQuery := `SELECT id FROM learning_modules WHERE session_id = $1 AND title = $2 AND sequence = $3 AND deleted_at IS NULL`
 	err := p.db.QueryRow(checkQuery, sessionID, baseName, sequence).Scan(&existingID)
 
-	// 이미 존재하는 경우 해당 ID 반환
+	// 이미 존재하는 경우 -on-existing에 따라 재사용/스킵/새로 생성
 	if err == nil {
-		log.Printf("기존 모듈 사용: ID %d (title: %s, sequence: %d)", existingID, baseName, sequence)
-		return existingID, nil
+		reuse, err := p.resolveExisting("모듈", existingID, baseName, false)
+		if err != nil {
+			return 0, err
+		}
+		if reuse {
+			loglevel.Infof("기존 모듈 사용: ID %d (title: %s, sequence: %d)", existingID, baseName, sequence)
+			return existingID, nil
+		}
 	}
 
 	// 새로운 모듈 생성
@@ -488,24 +1568,30 @@ func (p *Parser) createModule(name string, sessionID int64, sequence int, module
 		return 0, err
 	}
 
-	log.Printf("새 모듈 생성: ID %d (title: %s, sequence: %d)", id, baseName, sequence)
+	loglevel.Infof("새 모듈 생성: ID %d (title: %s, sequence: %d)", id, baseName, sequence)
 	return id, err
 }
 
 func (p *Parser) createSectionWithIndex(name string, moduleID int64, index int) (int64, error) {
 	// 섹션 sequence와 이름 파싱 (인덱스 fallback 사용)
 	sequence := extractSequenceWithIndex(name, index)
-	title := extractSectionTitle(name)
+	title := trimName(extractSectionTitle(name))
 
 	// 같은 title + sequence 조합의 섹션이 이미 있는지 확인 (삭제되지 않은 것만)
 	var existingID int64
 	checkQuery := `SELECT id FROM learning_sections WHERE module_id = $1 AND title = $2 AND sequence = $3 AND deleted_at IS NULL`
 	err := p.db.QueryRow(checkQuery, moduleID, title, sequence).Scan(&existingID)
 
-	// 이미 존재하는 경우 해당 ID 반환
+	// 이미 존재하는 경우 -on-existing에 따라 재사용/스킵/새로 생성
 	if err == nil {
-		log.Printf("기존 섹션 사용: ID %d (title: %s, sequence: %d)", existingID, title, sequence)
-		return existingID, nil
+		reuse, err := p.resolveExisting("섹션", existingID, title, false)
+		if err != nil {
+			return 0, err
+		}
+		if reuse {
+			loglevel.Infof("기존 섹션 사용: ID %d (title: %s, sequence: %d)", existingID, title, sequence)
+			return existingID, nil
+		}
 	}
 
 	// 새로운 섹션 생성
@@ -520,53 +1606,66 @@ func (p *Parser) createSectionWithIndex(name string, moduleID int64, index int)
 		return 0, err
 	}
 
-	log.Printf("새 섹션 생성: ID %d (title: %s, sequence: %d)", id, title, sequence)
+	loglevel.Infof("새 섹션 생성: ID %d (title: %s, sequence: %d)", id, title, sequence)
 	return id, err
 }
 
-// video 생성 함수 - parse_excel과 동일한 로직
-func (p *Parser) createVideoFromURL(title, videoURL, s3Path string) (int64, error) {
-	// testExam 모드가 아닐 때만 MD5 체크
-	var md5Hash string
-	var err error
-	if !p.testExam {
-		// URL에서 MD5 해시 계산
-		md5Hash, err = calculateURLMD5(videoURL)
-		if err != nil {
-			return 0, fmt.Errorf("MD5 계산 실패 -> %w", err)
-		}
+// findExistingVideo 영상의 MD5를 계산하고 같은 MD5의 비디오가 있으면 그 ID를 반환 (없으면 0)
+// testExam 모드에서는 MD5 체크 없이 항상 새 비디오를 만들도록 빈 MD5와 0을 반환
+func (p *Parser) findExistingVideo(videoURL, s3Path string) (string, int64, error) {
+	if p.testExam {
+		loglevel.Infof("테스트 모드: MD5 체크 없이 새 비디오 생성")
+		return "", 0, nil
+	}
 
-		// MD5 해시로 이미 존재하는 비디오 확인
-		var existingID int64
-		var existingUUID string
-		checkQuery := `SELECT id, uuid FROM videos WHERE md5_hash = $1 AND deleted_at IS NULL`
-		err = p.db.QueryRow(checkQuery, md5Hash).Scan(&existingID, &existingUUID)
+	// URL에서 MD5 해시 계산 (-md5-cache-dir이면 ETag가 같은 캐시 값 사용)
+	md5Hash, err := p.videoMD5(videoURL, s3Path)
+	if err != nil {
+		return "", 0, fmt.Errorf("MD5 계산 실패 -> %w", err)
+	}
 
-		// 이미 존재하는 경우 처리
-		if err == nil {
-			log.Printf("동일한 비디오 이미 존재 (MD5: %s): ID %d, UUID %s", md5Hash, existingID, existingUUID)
-			return existingID, nil
-		}
-	} else {
-		// testExam 모드에서는 항상 새 비디오 생성 (MD5 체크 없이)
-		log.Printf("테스트 모드: MD5 체크 없이 새 비디오 생성")
-		md5Hash = "" // 빈 MD5 해시
+	// MD5 해시로 이미 존재하는 비디오 확인
+	var existingID int64
+	var existingUUID string
+	checkQuery := `SELECT id, uuid FROM videos WHERE md5_hash = $1 AND deleted_at IS NULL`
+	err = p.queryRowPrepared(checkQuery, md5Hash).Scan(&existingID, &existingUUID)
+	if err == nil {
+		loglevel.Infof("동일한 비디오 이미 존재 (MD5: %s): ID %d, UUID %s", md5Hash, existingID, existingUUID)
+		return md5Hash, existingID, nil
 	}
+	return md5Hash, 0, nil
+}
 
+// video 생성 함수 - parse_excel과 동일한 로직 (기존 비디오 확인은 findExistingVideo)
+// 영상 길이를 읽지 못하면 길이 0인 비디오를 만들지 않고 오류를 반환해 재시도/격리되도록 함
+// sourceKey는 source_url로 저장할 키 (storedSourceKey), 길이와 썸네일은 원본 s3Path 기준
+func (p *Parser) createVideoFromURL(title, videoURL, s3Path, sourceKey, md5Hash string) (int64, error) {
 	// 새로운 UUID 생성
 	videoUUID := uuid.New().String()
 
 	// 영상 길이 추출
-	duration, _ := getVideoDuration(videoURL)
-
-	// 썸네일 생성 및 업로드
-	thumbnailS3Path := strings.TrimSuffix(s3Path, path.Ext(s3Path)) + "_thumbnail.png"
-	err = p.createAndUploadThumbnail(videoURL, thumbnailS3Path)
+	release := p.acquireProbe()
+	duration, err := p.probeDuration(videoURL, s3Path)
+	release()
 	if err != nil {
-		log.Printf("썸네일 생성 실패: %v", err)
+		return 0, fmt.Errorf("영상 길이 추출 실패 -> %w", err)
 	}
 
-	thumbnailURL := fmt.Sprintf("%s/%s", cloudfrontBaseURL, urlPathEncode(thumbnailS3Path))
+	// 썸네일 생성 및 업로드 (같은 위치에 이미 있으면 ffmpeg 없이 재사용)
+	thumbnailS3Path, thumbnailURL := p.thumbnailLocation(s3Path)
+	thumbnailExists := false
+	if !p.forceThumbnails {
+		thumbnailExists, err = p.objectExistsIn(p.thumbnailBucket, thumbnailS3Path)
+		if err != nil {
+			loglevel.Warnf("기존 썸네일 확인 실패, 새로 생성: %v", err)
+		}
+	}
+	if thumbnailExists {
+		loglevel.Infof("기존 썸네일 사용: %s", thumbnailS3Path)
+	} else if err := p.createAndUploadThumbnail(videoURL, thumbnailS3Path, ""); err != nil {
+		loglevel.Warnf("썸네일 생성 실패: %v", err)
+		p.recordFallback(s3Path, err)
+	}
 
 	// videos 테이블에 삽입
 	var id int64
@@ -575,24 +1674,267 @@ func (p *Parser) createVideoFromURL(title, videoURL, s3Path string) (int64, erro
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id`
 
-	err = p.db.QueryRow(query, videoUUID, title, videoURL, thumbnailURL, duration, md5Hash).Scan(&id)
+	err = p.db.QueryRow(query, videoUUID, title, p.storedURL(sourceKey), thumbnailURL, duration, md5Hash).Scan(&id)
 	if err != nil {
 		return 0, fmt.Errorf("비디오 DB 삽입 실패 -> %w", err)
 	}
 
-	log.Printf("비디오 생성 완료: ID %d, UUID %s", id, videoUUID)
+	loglevel.Infof("비디오 생성 완료: ID %d, UUID %s", id, videoUUID)
 	return id, nil
 }
 
+// videoMD5 영상의 MD5. 캐시가 있으면 S3 ETag가 같을 때 다운로드 없이 캐시 값을 쓰고, 새로 계산한 값은 캐시에 기록
+// ETag를 확인하지 못하면 캐시 없이 계산함
+func (p *Parser) videoMD5(videoURL, s3Path string) (string, error) {
+	var cacheKey, etag string
+	if p.md5Cache != nil {
+		head, err := p.s3Client.HeadObject(p.ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(p.bucketName),
+			Key:    aws.String(s3Path),
+		})
+		if err != nil {
+			loglevel.Warnf("ETag 확인 실패, MD5 캐시 사용 안 함: %v", err)
+		} else {
+			cacheKey = p.bucketName + "/" + s3Path
+			etag = aws.ToString(head.ETag)
+			md5Hash, ok, stale := p.md5Cache.Get(cacheKey, etag)
+			if ok {
+				loglevel.Debugf("MD5 캐시 사용: %s", s3Path)
+				return md5Hash, nil
+			}
+			if stale {
+				loglevel.Infof("ETag가 바뀌어 MD5 다시 계산: %s", s3Path)
+			}
+		}
+	}
+
+	release := p.acquireProbe()
+	md5Hash, err := calculateURLMD5(p.ctx, videoURL)
+	release()
+	if err != nil {
+		return "", err
+	}
+
+	if cacheKey != "" {
+		if err := p.md5Cache.Put(cacheKey, etag, md5Hash); err != nil {
+			loglevel.Warnf("MD5 캐시 기록 실패: %v", err)
+		}
+	}
+	return md5Hash, nil
+}
+
+// storedSourceKey source_url로 저장할 S3 키. -transcode이면 .mov를 .mp4로 변환해 그 키를 반환하고, 변환에 실패하면 원본 키를 씀
+func (p *Parser) storedSourceKey(videoURL, s3Path string) string {
+	mp4Key, ok := transcodedKey(s3Path)
+	if !ok || !p.transcode {
+		return s3Path
+	}
+	if err := p.transcodeToMP4(videoURL, mp4Key); err != nil {
+		loglevel.Warnf("mp4 변환 실패, 원본 사용: %v", err)
+		p.recordFallback(s3Path, err)
+		return s3Path
+	}
+	return mp4Key
+}
+
+// createVideoWithRetry 기존 비디오가 없으면 createVideoFromURL을 재시도 한도까지 간격을 늘려가며 시도하고, 모두 실패하면 파일을 격리
+// MD5 다운로드는 calculateURLMD5가 이미 재시도하므로 여기서는 한 번만 계산함
+// -transcode의 mp4 변환도 재시도마다 다시 하지 않도록 한 번만 함
+func (p *Parser) createVideoWithRetry(title, videoURL, s3Path string) (int64, error) {
+	videoURL = p.resolveVideoURL(videoURL)
+	md5Hash, existingID, err := p.findExistingVideo(videoURL, s3Path)
+	if err != nil {
+		loglevel.Warnf("⚠️  기존 비디오 확인 실패, 실패 목록으로 격리: %s -> %v", s3Path, err)
+		p.quarantine(s3Path, err)
+		return 0, err
+	}
+	if existingID != 0 {
+		return existingID, nil
+	}
+
+	sourceKey := p.storedSourceKey(videoURL, s3Path)
+
+	attempts := p.maxRetriesPerFile + 1
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		id, err := p.createVideoFromURL(title, videoURL, s3Path, sourceKey, md5Hash)
+		if err == nil {
+			return id, nil
+		}
+		lastErr = err
+		loglevel.Warnf("비디오 생성 실패 (%d/%d): %s -> %v", attempt, attempts, s3Path, err)
+		if attempt == attempts {
+			break
+		}
+		select {
+		case <-p.ctx.Done():
+			p.quarantine(s3Path, lastErr)
+			return 0, lastErr
+		case <-time.After(p.fileRetryDelay * time.Duration(attempt)):
+		}
+	}
+
+	loglevel.Warnf("⚠️  재시도 한도 초과, 실패 목록으로 격리: %s", s3Path)
+	p.quarantine(s3Path, lastErr)
+	return 0, lastErr
+}
+
+// quarantine 파일을 실패 목록(-failed-out)에 격리
+func (p *Parser) quarantine(s3Path string, err error) {
+	p.failedMu.Lock()
+	p.failedFiles = append(p.failedFiles, failedFile{S3Path: s3Path, Err: err})
+	p.failedMu.Unlock()
+	p.markFailed(s3Path)
+}
+
+// recordFallback 실패했지만 대체 처리로 계속 진행한 단계를 보고서에 기록 (격리 목록에는 넣지 않음)
+func (p *Parser) recordFallback(s3Path string, err error) {
+	p.failedMu.Lock()
+	p.failedFiles = append(p.failedFiles, failedFile{S3Path: s3Path, Err: err, Fallback: true})
+	p.failedMu.Unlock()
+	p.markFailed(s3Path)
+}
+
+// markFailed 처리 단계와 상관없이 실패한 파일 기록 (-dump-failed-urls)
+func (p *Parser) markFailed(s3Path string) {
+	p.failedMu.Lock()
+	if p.failedKeys == nil {
+		p.failedKeys = make(map[string]bool)
+	}
+	p.failedKeys[s3Path] = true
+	p.failedMu.Unlock()
+}
+
+// DumpFailedURLs 실패한 파일의 CloudFront URL을 한 줄에 하나씩 정렬해 저장 (콘텐츠 팀 전달용)
+func (p *Parser) DumpFailedURLs(outPath string) error {
+	p.failedMu.Lock()
+	urls := make([]string, 0, len(p.failedKeys))
+	for key := range p.failedKeys {
+		urls = append(urls, fmt.Sprintf("%s/%s", cloudfrontBaseURL, urlPathEncode(key)))
+	}
+	p.failedMu.Unlock()
+	sort.Strings(urls)
+
+	var data strings.Builder
+	for _, url := range urls {
+		data.WriteString(url)
+		data.WriteString("\n")
+	}
+	if err := SafeWriteFile(outPath, p.outputRoot, []byte(data.String())); err != nil {
+		return err
+	}
+	log.Printf("실패 URL 목록 저장: %s (%d개)", outPath, len(urls))
+	return nil
+}
+
+// ReportFailedFiles 격리된 파일과 대체 처리한 파일 목록 출력. outPath가 있으면 격리된 파일의 S3 키를 한 줄씩 기록
+// ffprobe/ffmpeg 오류는 분류별 개수도 출력
+func (p *Parser) ReportFailedFiles(outPath string) error {
+	if len(p.failedFiles) == 0 {
+		return nil
+	}
+
+	var quarantined, fallbacks []failedFile
+	categories := make(map[string]int)
+	for _, f := range p.failedFiles {
+		if f.Fallback {
+			fallbacks = append(fallbacks, f)
+		} else {
+			quarantined = append(quarantined, f)
+		}
+
+		var toolErr *toolError
+		if errors.As(f.Err, &toolErr) {
+			categories[toolErr.Category]++
+		}
+	}
+
+	var keys strings.Builder
+	if len(quarantined) > 0 {
+		fmt.Println("=== 재시도 한도를 넘겨 격리된 파일 ===")
+		for _, f := range quarantined {
+			fmt.Printf("  - %s (%v)\n", f.S3Path, f.Err)
+			keys.WriteString(f.S3Path)
+			keys.WriteString("\n")
+		}
+		fmt.Printf("총 %d개\n", len(quarantined))
+	}
+	if len(fallbacks) > 0 {
+		fmt.Println("=== 일부 단계가 실패해 대체 처리한 파일 ===")
+		for _, f := range fallbacks {
+			fmt.Printf("  - %s (%v)\n", f.S3Path, f.Err)
+		}
+		fmt.Printf("총 %d개\n", len(fallbacks))
+	}
+	for _, category := range []string{toolErrNetwork, toolErrCodec, toolErrLocalIO, toolErrUnknown} {
+		if n := categories[category]; n > 0 {
+			fmt.Printf("  ffprobe/ffmpeg %s 오류: %d개\n", category, n)
+		}
+	}
+
+	if outPath == "" {
+		return nil
+	}
+
+	if err := SafeWriteFile(outPath, p.outputRoot, []byte(keys.String())); err != nil {
+		return err
+	}
+	log.Printf("실패 목록 저장: %s", outPath)
+	return nil
+}
+
+// recordID -id-map용으로 S3 키와 ID를 기록 (섹션 병렬 처리 중에도 안전)
+func (p *Parser) recordID(s3Key string, videoID, contentID int64, contentType string) {
+	p.idMapMu.Lock()
+	p.idMapRows = append(p.idMapRows, idMapRow{S3Key: s3Key, VideoID: videoID, ContentID: contentID, ContentType: contentType})
+	p.idMapMu.Unlock()
+}
+
+// WriteIDMap 기록된 S3 키별 ID를 CSV로 저장. 알 수 없는 ID는 빈 칸
+func (p *Parser) WriteIDMap(outPath string) error {
+	p.idMapMu.Lock()
+	rows := append([]idMapRow(nil), p.idMapRows...)
+	p.idMapMu.Unlock()
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].S3Key < rows[j].S3Key })
+
+	formatID := func(id int64) string {
+		if id == 0 {
+			return ""
+		}
+		return strconv.FormatInt(id, 10)
+	}
+
+	var buf strings.Builder
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"s3_key", "video_id", "content_id", "content_type"}); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := w.Write([]string{r.S3Key, formatID(r.VideoID), formatID(r.ContentID), r.ContentType}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	if err := SafeWriteFile(outPath, p.outputRoot, []byte(buf.String())); err != nil {
+		return err
+	}
+	log.Printf("ID 매핑 저장: %s (%d개)", outPath, len(rows))
+	return nil
+}
+
 func (p *Parser) createLectureWithVideoID(title string, videoID int64) (int64, error) {
 	// 해당 video_id로 이미 존재하는 lecture가 있는지 확인
 	var existingID int64
 	checkQuery := `SELECT id FROM lectures WHERE lecture_video_id = $1`
-	err := p.db.QueryRow(checkQuery, videoID).Scan(&existingID)
+	err := p.queryRowPrepared(checkQuery, videoID).Scan(&existingID)
 
 	// 이미 존재하는 경우 해당 ID 반환
 	if err == nil {
-		log.Printf("기존 강의 사용: ID %d (video_id: %d)", existingID, videoID)
+		loglevel.Infof("기존 강의 사용: ID %d (video_id: %d)", existingID, videoID)
 		return existingID, nil
 	}
 
@@ -612,22 +1954,22 @@ func (p *Parser) updateExerciseSolutionWithVideoID(exerciseRefID string, videoID
 		// 먼저 해당 exercise의 solution_video_id가 이미 설정되어 있는지 확인
 		var existingVideoID sql.NullInt64
 		checkQuery := `SELECT solution_video_id FROM exercises WHERE ref_id = $1`
-		err := p.db.QueryRow(checkQuery, exerciseRefID).Scan(&existingVideoID)
+		err := p.queryRowPrepared(checkQuery, exerciseRefID).Scan(&existingVideoID)
 
 		// 레코드가 없는 경우
 		if errors.Is(err, sql.ErrNoRows) {
-			log.Printf("exercise_ref_id %s를 찾을 수 없습니다", exerciseRefID)
+			loglevel.Warnf("exercise_ref_id %s를 찾을 수 없습니다", exerciseRefID)
 			return fmt.Errorf("exercise not found: %s", exerciseRefID)
 		}
 
 		// 이미 비디오가 설정되어 있는 경우
 		if existingVideoID.Valid && existingVideoID.Int64 > 0 {
-			log.Printf("해설 영상 이미 존재: exercise_ref_id %s (video_id: %d)", exerciseRefID, existingVideoID.Int64)
+			loglevel.Infof("해설 영상 이미 존재: exercise_ref_id %s (video_id: %d)", exerciseRefID, existingVideoID.Int64)
 			return nil
 		}
 	}
 
-	log.Printf("해설 영상 처리: exercise_ref_id %s", exerciseRefID)
+	loglevel.Infof("해설 영상 처리: exercise_ref_id %s", exerciseRefID)
 
 	// exercises 테이블 업데이트
 	query := `UPDATE exercises SET solution_video_id = $1 WHERE ref_id = $2`
@@ -637,45 +1979,91 @@ func (p *Parser) updateExerciseSolutionWithVideoID(exerciseRefID string, videoID
 }
 
 func (p *Parser) processSectionContents(s3Prefix, moduleName, sectionName string, sectionID int64, studentID int, moduleType string) error {
-	log.Printf("S3 파일 목록 조회 시작: %s/%s/%s", s3Prefix, moduleName, sectionName)
+	loglevel.Infof("S3 파일 목록 조회 시작: %s/%s/%s", s3Prefix, moduleName, sectionName)
+	p.progress.update(func(t *progressTracker) {
+		t.Section = sectionName
+		if sectionName == "" {
+			t.Section = p.defaultSectionName
+		}
+	})
 	files, err := p.GetFilesInSection(s3Prefix, moduleName, sectionName)
 	if err != nil {
 		return err
 	}
-	log.Printf("S3 파일 %d개 발견", len(files))
+	loglevel.Infof("S3 파일 %d개 발견", len(files))
+
+	// 강의/해설 이름 규칙에 맞지 않는 파일은 -allow-unnamed가 없으면 제외
+	if !p.allowUnnamed {
+		named := files[:0]
+		for _, file := range files {
+			if classifyFile(path.Base(file)) == "" {
+				loglevel.Warnf("⚠️  이름 규칙에 맞지 않는 파일 스킵 (-allow-unnamed로 처리 가능): %s", file)
+				continue
+			}
+			named = append(named, file)
+		}
+		files = named
+	}
+
+	// 제목 템플릿용 모듈/섹션 제목
+	moduleTitle := extractModuleTitle(moduleName)
+	sectionTitle := extractSectionTitle(sectionName)
+	if sectionName == "" {
+		sectionTitle = extractSectionTitle(p.defaultSectionName)
+	}
 
 	// 기존 DB 콘텐츠 확인
 	var existingCount int
 	checkQuery := `SELECT COUNT(*) FROM learning_contents WHERE section_id = $1 AND user_id = $2 AND deleted_at IS NULL`
 	err = p.db.QueryRow(checkQuery, sectionID, studentID).Scan(&existingCount)
 	if err != nil {
-		log.Printf("DB 콘텐츠 수 확인 실패: %v", err)
+		loglevel.Warnf("DB 콘텐츠 수 확인 실패: %v", err)
 		existingCount = 0
 	}
 
-	log.Printf("섹션 콘텐츠 비교: section_id=%d, user_id=%d, S3파일=%d개, DB콘텐츠=%d개",
+	loglevel.Infof("섹션 콘텐츠 비교: section_id=%d, user_id=%d, S3파일=%d개, DB콘텐츠=%d개",
 		sectionID, studentID, len(files), existingCount)
 
-	// force 옵션이 아니고, S3 파일 수와 DB 콘텐츠 수가 같으면 스킵
-	if !p.forceReplaceVideo && len(files) == existingCount && existingCount > 0 {
-		log.Printf("S3 파일과 DB 콘텐츠 개수가 일치 (%d개), 처리 스킵", existingCount)
+	// force 옵션이 아니고, S3 파일 수와 DB 콘텐츠 수가 같으면 스킵 (-reconcile이면 파일별로 확인)
+	if !p.forceReplaceVideo && !p.reconcile && len(files) == existingCount && existingCount > 0 {
+		loglevel.Infof("S3 파일과 DB 콘텐츠 개수가 일치 (%d개), 처리 스킵", existingCount)
 		return nil
 	}
 
 	if p.forceReplaceVideo {
-		log.Printf("force-replace-video 옵션으로 기존 콘텐츠의 비디오만 재생성")
+		loglevel.Infof("force-replace-video 옵션으로 기존 콘텐츠의 비디오만 재생성")
 	} else if len(files) != existingCount {
-		log.Printf("S3 파일(%d개)과 DB 콘텐츠(%d개) 개수 불일치, 누락된 콘텐츠 추가 진행", len(files), existingCount)
+		loglevel.Infof("S3 파일(%d개)과 DB 콘텐츠(%d개) 개수 불일치, 누락된 콘텐츠 추가 진행", len(files), existingCount)
 	}
 
-	// 파일들을 contentSequence 기준으로 정렬
-	sort.Slice(files, func(i, j int) bool {
-		filenameI := path.Base(files[i])
-		filenameJ := path.Base(files[j])
-		seqI := extractSequence(filenameI)
-		seqJ := extractSequence(filenameJ)
-		return seqI < seqJ
-	})
+	// 파일들을 contentSequence 기준으로 정렬 (-sort=key이면 GetFilesInSection의 S3 키 사전순 유지)
+	if p.sortMode == "sequence" {
+		sort.Slice(files, func(i, j int) bool {
+			filenameI := path.Base(files[i])
+			filenameJ := path.Base(files[j])
+			seqI := extractSequence(filenameI)
+			seqJ := extractSequence(filenameJ)
+			return seqI < seqJ
+		})
+	}
+
+	// -reconcile이면 sequence별 기존 콘텐츠가 가리키는 영상의 S3 키를 미리 조회
+	var existingKeys map[contentKey]string
+	if p.reconcile {
+		existingKeys, err = p.sectionContentKeys(sectionID, studentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// -batch-insert-contents이면 기존 콘텐츠를 한 번에 조회하고 새 콘텐츠는 모아서 마지막에 생성
+	var batch *contentBatch
+	if p.batchContents {
+		batch, err = p.newContentBatch(sectionID, studentID)
+		if err != nil {
+			return err
+		}
+	}
 
 	exerciseCounter := 1
 	lectureCounter := 0
@@ -692,20 +2080,30 @@ func (p *Parser) processSectionContents(s3Prefix, moduleName, sectionName string
 	// 파일 처리
 	for i, s3Path := range files {
 		filename := path.Base(s3Path)
-		videoURL := fmt.Sprintf("%s/%s", cloudfrontBaseURL, urlPathEncode(s3Path))
+		loglevel.Infof("파일 처리 %d/%d: %s", i+1, len(files), filename)
+		p.progress.update(func(t *progressTracker) {
+			t.File = s3Path
+			t.FilesStarted++
+		})
 
-		log.Printf("파일 처리 %d/%d: %s", i+1, len(files), filename)
+		videoURL, err := p.readURL(s3Path)
+		if err != nil {
+			loglevel.Warnf("영상 URL 생성 실패: %v", err)
+			p.markFailed(s3Path)
+			continue
+		}
 
-		// 파일명에서 sequence 추출
-		contentSequence := extractSequence(filename)
+		contentSequence := fileSequence(filename, i, p.sortMode)
 
 		if isSolutionFile(filename) {
 			// 해설 영상 처리
 			// exerciseGroupID := extractExerciseGroupID(filename)
 			exerciseRefID := extractExerciseRefID(filename)
-			title := fmt.Sprintf("해설 영상 - %s", extractTitle(filename))
+			title := fmt.Sprintf("해설 영상 - %s", renderTitle(p.titleTemplate, moduleTitle, sectionTitle, extractTitle(filename), exerciseCounter))
 			var exampleTitle string
-			if moduleType == "exam" {
+			if moduleType == "exam" && sectionName == "" {
+				exampleTitle = extractSectionTitle(p.defaultSectionName)
+			} else if moduleType == "exam" {
 				exampleTitle = extractSectionTitle(sectionName)
 			} else {
 				exampleTitle = generateExerciseTitle("example", exerciseCounter)
@@ -713,82 +2111,114 @@ func (p *Parser) processSectionContents(s3Prefix, moduleName, sectionName string
 
 			// 기존 콘텐츠 확인
 			var existingContentID int64
-			checkQuery := `SELECT id FROM learning_contents WHERE section_id = $1 AND sequence = $2 AND content_type = 'exercise' AND user_id = $3 AND deleted_at IS NULL`
-			err := p.db.QueryRow(checkQuery, sectionID, contentSequence, studentID).Scan(&existingContentID)
+			if batch != nil {
+				existingContentID, err = batch.lookup("exercise", contentSequence)
+			} else {
+				checkQuery := `SELECT id FROM learning_contents WHERE section_id = $1 AND sequence = $2 AND content_type = 'exercise' AND user_id = $3 AND deleted_at IS NULL`
+				err = p.queryRowPrepared(checkQuery, sectionID, contentSequence, studentID).Scan(&existingContentID)
+			}
 
 			if err == nil {
 				// 기존 콘텐츠가 있음
-				if p.forceReplaceVideo && !p.testExam {
-					// force-replace-video 옵션: 기존 콘텐츠의 해설 비디오 교체
-					log.Printf("기존 연습 콘텐츠의 해설 비디오 교체: content_id %d, exercise_ref_id %s", existingContentID, exerciseRefID)
+				replace := p.forceReplaceVideo
+				if p.reconcile && !p.matchesExistingKey(existingKeys, contentKey{"exercise", contentSequence}, s3Path) {
+					loglevel.Infof("기존 연습 콘텐츠가 다른 영상을 가리킴 (sequence: %d), 교체: %s", contentSequence, s3Path)
+					replace = true
+				}
+				if replace && !p.testExam {
+					// force-replace-video 옵션 또는 -reconcile 불일치: 기존 콘텐츠의 해설 비디오 교체
+					loglevel.Infof("기존 연습 콘텐츠의 해설 비디오 교체: content_id %d, exercise_ref_id %s", existingContentID, exerciseRefID)
 
 					// 새 비디오 생성
 					var videoID int64
-					videoID, err = p.createVideoFromURL(title, videoURL, s3Path)
+					videoID, err = p.createVideoWithRetry(title, videoURL, s3Path)
 					if err != nil {
-						log.Printf("해설 비디오 생성 실패: %v", err)
+						loglevel.Warnf("해설 비디오 생성 실패: %v", err)
 						continue
 					}
 
 					// exercise의 solution_video_id 업데이트
 					err = p.updateExerciseSolutionWithVideoID(exerciseRefID, videoID)
 					if err != nil {
-						log.Printf("해설 영상 업데이트 실패: %v", err)
+						loglevel.Warnf("해설 영상 업데이트 실패: %v", err)
+						p.markFailed(s3Path)
 						continue
 					}
 
-					log.Printf("해설 비디오 교체 완료: exercise_ref_id %s, new_video_id %d", exerciseRefID, videoID)
+					loglevel.Infof("해설 비디오 교체 완료: exercise_ref_id %s, new_video_id %d", exerciseRefID, videoID)
+					p.recordID(s3Path, videoID, existingContentID, "exercise")
 				} else {
 					// 일반 모드에서는 기존 콘텐츠가 있으면 스킵
-					log.Printf("기존 연습 콘텐츠 존재 (sequence: %d), 스킵", contentSequence)
+					loglevel.Infof("기존 연습 콘텐츠 존재 (sequence: %d), 스킵", contentSequence)
+					p.recordID(s3Path, 0, existingContentID, "exercise")
 				}
 				exerciseCounter++
 				continue
 			}
 
 			// 새로운 콘텐츠 생성 (기존 콘텐츠가 없을 때)
+			var videoID int64
 			if !p.testExam {
 				// video 생성
-				videoID, err := p.createVideoFromURL(title, videoURL, s3Path)
+				videoID, err = p.createVideoWithRetry(title, videoURL, s3Path)
 				if err != nil {
-					log.Printf("해설 비디오 생성 실패: %v", err)
+					loglevel.Warnf("해설 비디오 생성 실패: %v", err)
 					continue
 				}
 
 				// exercise 업데이트
 				err = p.updateExerciseSolutionWithVideoID(exerciseRefID, videoID)
 				if err != nil {
-					log.Printf("해설 영상 업데이트 실패: %v", err)
+					loglevel.Warnf("해설 영상 업데이트 실패: %v", err)
+					p.markFailed(s3Path)
 					continue
 				}
 			} else {
-				log.Printf("테스트 모드: 해설 비디오 생성 스킵 (exercise_ref_id: %s)", exerciseRefID)
+				loglevel.Infof("테스트 모드: 해설 비디오 생성 스킵 (exercise_ref_id: %s)", exerciseRefID)
 			}
 
-			_ = p.createExerciseContent(exerciseRefID, sectionID, studentID, contentSequence, "example", exampleTitle)
+			if batch != nil {
+				batch.add(pendingContent{title: exampleTitle, contentType: "exercise", exerciseRefID: exerciseRefID, exerciseType: "example", sequence: contentSequence, s3Path: s3Path, videoID: videoID})
+			} else {
+				contentID, err := p.createExerciseContent(exerciseRefID, sectionID, studentID, contentSequence, "example", exampleTitle)
+				if err != nil {
+					loglevel.Warnf("연습 콘텐츠 생성 실패: %v", err)
+					p.markFailed(s3Path)
+				}
+				p.recordID(s3Path, videoID, contentID, "exercise")
+			}
 			exerciseCounter++
 		} else {
 			// 강의 영상 처리
-			title := extractTitle(filename)
+			title := renderTitle(p.titleTemplate, moduleTitle, sectionTitle, extractTitle(filename), lectureCounter+1)
 			lectureTitle := generateLectureTitle(moduleType, lectureCount, lectureCounter)
 
 			// 기존 콘텐츠 확인
 			var existingContentID int64
 			var existingLectureID int64
-			checkQuery := `SELECT id, lecture_id FROM learning_contents WHERE section_id = $1 AND sequence = $2 AND content_type = 'lecture' AND user_id = $3 AND deleted_at IS NULL`
-			err := p.db.QueryRow(checkQuery, sectionID, contentSequence, studentID).Scan(&existingContentID, &existingLectureID)
+			if batch != nil {
+				existingContentID, err = batch.lookup("lecture", contentSequence)
+			} else {
+				checkQuery := `SELECT id, lecture_id FROM learning_contents WHERE section_id = $1 AND sequence = $2 AND content_type = 'lecture' AND user_id = $3 AND deleted_at IS NULL`
+				err = p.queryRowPrepared(checkQuery, sectionID, contentSequence, studentID).Scan(&existingContentID, &existingLectureID)
+			}
 
 			if err == nil {
 				// 기존 콘텐츠가 있음
-				if p.forceReplaceVideo {
-					// force-replace-video 옵션: 기존 콘텐츠의 비디오 교체
-					log.Printf("기존 강의 콘텐츠의 비디오 교체: content_id %d, lecture_id %d", existingContentID, existingLectureID)
+				replace := p.forceReplaceVideo
+				if p.reconcile && !p.matchesExistingKey(existingKeys, contentKey{"lecture", contentSequence}, s3Path) {
+					loglevel.Infof("기존 강의 콘텐츠가 다른 영상을 가리킴 (sequence: %d), 교체: %s", contentSequence, s3Path)
+					replace = true
+				}
+				if replace {
+					// force-replace-video 옵션 또는 -reconcile 불일치: 기존 콘텐츠의 비디오 교체
+					loglevel.Infof("기존 강의 콘텐츠의 비디오 교체: content_id %d, lecture_id %d", existingContentID, existingLectureID)
 
 					// 새 비디오 생성
 					var videoID int64
-					videoID, err = p.createVideoFromURL(title, videoURL, s3Path)
+					videoID, err = p.createVideoWithRetry(title, videoURL, s3Path)
 					if err != nil {
-						log.Printf("강의 비디오 생성 실패: %v", err)
+						loglevel.Warnf("강의 비디오 생성 실패: %v", err)
 						continue
 					}
 
@@ -796,14 +2226,17 @@ func (p *Parser) processSectionContents(s3Prefix, moduleName, sectionName string
 					updateQuery := `UPDATE lectures SET lecture_video_id = $1 WHERE id = $2`
 					_, err = p.db.Exec(updateQuery, videoID, existingLectureID)
 					if err != nil {
-						log.Printf("강의 비디오 업데이트 실패: %v", err)
+						loglevel.Warnf("강의 비디오 업데이트 실패: %v", err)
+						p.markFailed(s3Path)
 						continue
 					}
 
-					log.Printf("강의 비디오 교체 완료: lecture_id %d, new_video_id %d", existingLectureID, videoID)
+					loglevel.Infof("강의 비디오 교체 완료: lecture_id %d, new_video_id %d", existingLectureID, videoID)
+					p.recordID(s3Path, videoID, existingContentID, "lecture")
 				} else {
 					// 일반 모드에서는 기존 콘텐츠가 있으면 스킵
-					log.Printf("기존 강의 콘텐츠 존재 (sequence: %d), 스킵", contentSequence)
+					loglevel.Infof("기존 강의 콘텐츠 존재 (sequence: %d), 스킵", contentSequence)
+					p.recordID(s3Path, 0, existingContentID, "lecture")
 				}
 				lectureCounter++
 				continue
@@ -811,87 +2244,349 @@ func (p *Parser) processSectionContents(s3Prefix, moduleName, sectionName string
 
 			// 새로운 콘텐츠 생성 (기존 콘텐츠가 없을 때)
 			// video 생성
-			videoID, err := p.createVideoFromURL(title, videoURL, s3Path)
+			videoID, err := p.createVideoWithRetry(title, videoURL, s3Path)
 			if err != nil {
-				log.Printf("강의 비디오 생성 실패: %v", err)
+				loglevel.Warnf("강의 비디오 생성 실패: %v", err)
 				continue
 			}
 
 			// lecture 생성
 			lectureID, err := p.createLectureWithVideoID(title, videoID)
 			if err != nil {
-				log.Printf("강의 생성 실패: %v", err)
+				loglevel.Warnf("강의 생성 실패: %v", err)
+				p.markFailed(s3Path)
 				continue
 			}
 
-			_ = p.createLectureContent(lectureID, sectionID, studentID, contentSequence, lectureTitle)
+			if batch != nil {
+				batch.add(pendingContent{title: lectureTitle, contentType: "lecture", lectureID: lectureID, sequence: contentSequence, s3Path: s3Path, videoID: videoID})
+			} else {
+				contentID, err := p.createLectureContent(lectureID, sectionID, studentID, contentSequence, lectureTitle)
+				if err != nil {
+					loglevel.Warnf("강의 콘텐츠 생성 실패: %v", err)
+					p.markFailed(s3Path)
+				}
+				p.recordID(s3Path, videoID, contentID, "lecture")
+			}
 			lectureCounter++
 		}
 	}
 
+	if batch != nil {
+		return p.flushContentBatch(batch)
+	}
 	return nil
 }
 
-func (p *Parser) createLectureContent(lectureID, sectionID int64, studentID, sequence int, title string) error {
-	// 새로운 강의 콘텐츠 생성 (중복 체크는 호출하는 곳에서 이미 함)
+// sectionContentKeys 섹션의 기존 콘텐츠가 가리키는 영상의 S3 키 (강의는 lecture_video_id, 연습은 solution_video_id)
+// 영상이 없거나 source_url이 알려진 CloudFront 주소가 아니면 빈 문자열
+func (p *Parser) sectionContentKeys(sectionID int64, studentID int) (map[contentKey]string, error) {
 	query := `
-		INSERT INTO learning_contents (title, content_type, lecture_id, exercise_id, required_exercise_group_id, sequence, section_id, user_id)
-		VALUES ($1, 'lecture', $2, NULL, NULL, $3, $4, $5)`
+		SELECT lc.content_type, lc.sequence, COALESCE(v.source_url, '')
+		FROM learning_contents lc
+		LEFT JOIN lectures l ON l.id = lc.lecture_id
+		LEFT JOIN exercises e ON e.id = lc.exercise_id
+		LEFT JOIN videos v ON v.id = COALESCE(l.lecture_video_id, e.solution_video_id)
+		WHERE lc.section_id = $1 AND lc.user_id = $2 AND lc.deleted_at IS NULL`
+
+	rows, err := p.db.Query(query, sectionID, studentID)
+	if err != nil {
+		return nil, fmt.Errorf("기존 콘텐츠 영상 조회 실패 -> %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
 
-	_, err := p.db.Exec(query, title, lectureID, sequence, sectionID, studentID)
-	if err == nil {
-		log.Printf("새 강의 콘텐츠 생성: title %s (sequence: %d)", title, sequence)
+	keys := make(map[contentKey]string)
+	for rows.Next() {
+		var key contentKey
+		var sourceURL string
+		if err := rows.Scan(&key.contentType, &key.sequence, &sourceURL); err != nil {
+			return nil, fmt.Errorf("기존 콘텐츠 영상 조회 실패 -> %w", err)
+		}
+		s3Key, _ := p.urlToS3Key(sourceURL)
+		keys[key] = norm.NFC.String(s3Key)
 	}
-	return err
+	return keys, rows.Err()
 }
 
-func (p *Parser) createExerciseContent(exerciseRefID string, sectionID int64, studentID, sequence int, exerciseType, title string) error {
-	// 새로운 연습 콘텐츠 생성 (중복 체크는 호출하는 곳에서 이미 함)
-	query := `
-		SELECT id FROM exercises WHERE ref_id = $1
-		LIMIT 1
-	`
-
-	var exerciseID int64
-	err := p.db.QueryRow(query, exerciseRefID).Scan(&exerciseID)
-	if err != nil {
-		return err
+// matchesExistingKey 같은 sequence의 기존 콘텐츠가 이 파일(또는 -transcode로 만든 .mp4)을 가리키는지
+func (p *Parser) matchesExistingKey(existingKeys map[contentKey]string, key contentKey, s3Path string) bool {
+	existing := existingKeys[key]
+	if existing == "" {
+		return false
 	}
+	if existing == norm.NFC.String(s3Path) {
+		return true
+	}
+	mp4Key, ok := transcodedKey(s3Path)
+	return ok && existing == norm.NFC.String(mp4Key)
+}
 
-	query = `
-		INSERT INTO learning_contents (title, content_type, lecture_id, exercise_id, required_exercise_group_id, exercise_type, sequence, section_id, user_id)
-		VALUES ($1, 'exercise', NULL, $2, NULL, $3, $4, $5, $6)`
+// contentInsertChunk 여러 행 INSERT 한 번에 넣는 최대 행 수 (PostgreSQL 파라미터 65535개 제한 이내)
+const contentInsertChunk = 500
 
-	_, err = p.db.Exec(query, title, exerciseID, exerciseType, sequence, sectionID, studentID)
-	if err == nil {
-		log.Printf("새 연습 콘텐츠 생성: title %s (sequence: %d)", title, sequence)
-	}
-	return err
+type contentKey struct {
+	contentType string
+	sequence    int
 }
 
-func (p *Parser) createAndUploadThumbnail(videoURL, s3Path string) error {
-	// 임시 파일명 생성
-	tempFile := fmt.Sprintf("/tmp/thumbnail_%d.png", time.Now().UnixNano())
-	defer func() {
-		_ = os.Remove(tempFile)
-	}()
+// pendingContent -batch-insert-contents에서 섹션 끝에 생성할 learning_contents 행
+type pendingContent struct {
+	title         string
+	contentType   string // lecture, exercise
+	lectureID     int64
+	exerciseRefID string
+	exerciseType  string
+	sequence      int
+	s3Path        string
+	videoID       int64
+}
 
-	// 경로 검증 및 ffmpeg 실행을 위한 안전한 경로
-	cleanPath, err := ValidateTempPath(tempFile)
+// contentBatch 한 섹션의 기존 콘텐츠와 생성 대기 중인 콘텐츠
+// 파일마다 하던 중복 확인 SELECT와 INSERT를 섹션당 조회 1번 + 타입별 INSERT로 줄임
+type contentBatch struct {
+	sectionID int64
+	studentID int
+	existing  map[contentKey]int64 // 생성 대기 중인 콘텐츠는 ID 0 (같은 sequence가 또 나오면 스킵)
+	rows      []pendingContent
+}
+
+// newContentBatch 섹션의 삭제되지 않은 콘텐츠를 한 번에 조회
+func (p *Parser) newContentBatch(sectionID int64, studentID int) (*contentBatch, error) {
+	rows, err := p.db.Query(`SELECT id, content_type, sequence FROM learning_contents WHERE section_id = $1 AND user_id = $2 AND deleted_at IS NULL`, sectionID, studentID)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("기존 콘텐츠 조회 실패 -> %w", err)
 	}
+	defer rows.Close()
 
-	// ffmpeg로 썸네일 생성 (bash에서 성공했던 방식과 동일)
-	cmd := exec.Command("ffmpeg", "-i", videoURL, "-vframes", "1", "-f", "image2", cleanPath, "-y")
+	b := &contentBatch{sectionID: sectionID, studentID: studentID, existing: make(map[contentKey]int64)}
+	for rows.Next() {
+		var id int64
+		var key contentKey
+		if err := rows.Scan(&id, &key.contentType, &key.sequence); err != nil {
+			return nil, fmt.Errorf("기존 콘텐츠 스캔 실패 -> %w", err)
+		}
+		b.existing[key] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("기존 콘텐츠 조회 실패 -> %w", err)
+	}
+	return b, nil
+}
 
-	// 에러 출력 캡처
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("썸네일 생성 실패: %w, 출력: %s", err, string(output))
+// lookup 파일별 중복 확인 쿼리와 같은 결과. 없으면 sql.ErrNoRows
+func (b *contentBatch) lookup(contentType string, sequence int) (int64, error) {
+	id, ok := b.existing[contentKey{contentType, sequence}]
+	if !ok {
+		return 0, sql.ErrNoRows
 	}
+	return id, nil
+}
 
-	// S3에 업로드
+func (b *contentBatch) add(c pendingContent) {
+	b.existing[contentKey{c.contentType, c.sequence}] = 0
+	b.rows = append(b.rows, c)
+}
+
+// flushContentBatch 모아둔 콘텐츠를 타입별 여러 행 INSERT로 생성하고 ID 기록
+func (p *Parser) flushContentBatch(b *contentBatch) error {
+	var lectures, exercises []pendingContent
+	var refIDs []string
+	for _, c := range b.rows {
+		if c.contentType == "lecture" {
+			lectures = append(lectures, c)
+		} else {
+			exercises = append(exercises, c)
+			refIDs = append(refIDs, c.exerciseRefID)
+		}
+	}
+
+	// 연습 콘텐츠의 exercise_id를 한 번에 조회
+	exerciseIDs := make(map[string]int64)
+	if len(refIDs) > 0 {
+		rows, err := p.db.Query(`SELECT ref_id, id FROM exercises WHERE ref_id = ANY($1)`, pq.Array(refIDs))
+		if err != nil {
+			return fmt.Errorf("연습문제 조회 실패 -> %w", err)
+		}
+		for rows.Next() {
+			var refID string
+			var id int64
+			if err := rows.Scan(&refID, &id); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("연습문제 스캔 실패 -> %w", err)
+			}
+			if _, ok := exerciseIDs[refID]; !ok {
+				exerciseIDs[refID] = id
+			}
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("연습문제 조회 실패 -> %w", err)
+		}
+	}
+
+	var found []pendingContent
+	for _, c := range exercises {
+		if _, ok := exerciseIDs[c.exerciseRefID]; !ok {
+			loglevel.Warnf("exercise_ref_id %s를 찾을 수 없어 연습 콘텐츠 생성 스킵 (sequence: %d)", c.exerciseRefID, c.sequence)
+			p.recordID(c.s3Path, c.videoID, 0, "exercise")
+			p.markFailed(c.s3Path)
+			continue
+		}
+		found = append(found, c)
+	}
+
+	if err := p.insertContents(b, lectures, nil); err != nil {
+		return err
+	}
+	return p.insertContents(b, found, exerciseIDs)
+}
+
+// insertContents 같은 타입의 콘텐츠를 contentInsertChunk개씩 여러 행 INSERT
+// 컬럼은 createLectureContent/createExerciseContent와 같음. exerciseIDs가 nil이면 강의 콘텐츠
+func (p *Parser) insertContents(b *contentBatch, contents []pendingContent, exerciseIDs map[string]int64) error {
+	for start := 0; start < len(contents); start += contentInsertChunk {
+		end := start + contentInsertChunk
+		if end > len(contents) {
+			end = len(contents)
+		}
+		chunk := contents[start:end]
+
+		var query strings.Builder
+		var args []any
+		if exerciseIDs == nil {
+			query.WriteString(`INSERT INTO learning_contents (title, content_type, lecture_id, exercise_id, required_exercise_group_id, sequence, section_id, user_id) VALUES `)
+		} else {
+			query.WriteString(`INSERT INTO learning_contents (title, content_type, lecture_id, exercise_id, required_exercise_group_id, exercise_type, sequence, section_id, user_id) VALUES `)
+		}
+		for i, c := range chunk {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			n := len(args)
+			if exerciseIDs == nil {
+				fmt.Fprintf(&query, "($%d, 'lecture', $%d, NULL, NULL, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5)
+				args = append(args, c.title, c.lectureID, c.sequence, b.sectionID, b.studentID)
+			} else {
+				fmt.Fprintf(&query, "($%d, 'exercise', NULL, $%d, NULL, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6)
+				args = append(args, c.title, exerciseIDs[c.exerciseRefID], c.exerciseType, c.sequence, b.sectionID, b.studentID)
+			}
+		}
+		query.WriteString(" RETURNING id, sequence")
+
+		// RETURNING 순서에 기대지 않고 sequence로 대응 (섹션 안에서 타입별 sequence는 add에서 중복 제거됨)
+		rows, err := p.db.Query(query.String(), args...)
+		if err != nil {
+			for _, c := range chunk {
+				p.markFailed(c.s3Path)
+			}
+			return fmt.Errorf("콘텐츠 일괄 생성 실패 -> %w", err)
+		}
+		ids := make(map[int]int64, len(chunk))
+		for rows.Next() {
+			var id int64
+			var sequence int
+			if err := rows.Scan(&id, &sequence); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("콘텐츠 일괄 생성 결과 스캔 실패 -> %w", err)
+			}
+			ids[sequence] = id
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("콘텐츠 일괄 생성 실패 -> %w", err)
+		}
+
+		for _, c := range chunk {
+			p.recordID(c.s3Path, c.videoID, ids[c.sequence], c.contentType)
+		}
+		loglevel.Infof("콘텐츠 일괄 생성: section_id %d, %d개", b.sectionID, len(chunk))
+	}
+	return nil
+}
+
+func (p *Parser) createLectureContent(lectureID, sectionID int64, studentID, sequence int, title string) (int64, error) {
+	// 새로운 강의 콘텐츠 생성 (중복 체크는 호출하는 곳에서 이미 함)
+	query := `
+		INSERT INTO learning_contents (title, content_type, lecture_id, exercise_id, required_exercise_group_id, sequence, section_id, user_id)
+		VALUES ($1, 'lecture', $2, NULL, NULL, $3, $4, $5)
+		RETURNING id`
+
+	var id int64
+	err := p.db.QueryRow(query, title, lectureID, sequence, sectionID, studentID).Scan(&id)
+	if err == nil {
+		loglevel.Infof("새 강의 콘텐츠 생성: title %s (sequence: %d)", title, sequence)
+	}
+	return id, err
+}
+
+func (p *Parser) createExerciseContent(exerciseRefID string, sectionID int64, studentID, sequence int, exerciseType, title string) (int64, error) {
+	// 새로운 연습 콘텐츠 생성 (중복 체크는 호출하는 곳에서 이미 함)
+	query := `
+		SELECT id FROM exercises WHERE ref_id = $1
+		LIMIT 1
+	`
+
+	var exerciseID int64
+	err := p.db.QueryRow(query, exerciseRefID).Scan(&exerciseID)
+	if err != nil {
+		return 0, err
+	}
+
+	query = `
+		INSERT INTO learning_contents (title, content_type, lecture_id, exercise_id, required_exercise_group_id, exercise_type, sequence, section_id, user_id)
+		VALUES ($1, 'exercise', NULL, $2, NULL, $3, $4, $5, $6)
+		RETURNING id`
+
+	var id int64
+	err = p.db.QueryRow(query, title, exerciseID, exerciseType, sequence, sectionID, studentID).Scan(&id)
+	if err == nil {
+		loglevel.Infof("새 연습 콘텐츠 생성: title %s (sequence: %d)", title, sequence)
+	}
+	return id, err
+}
+
+// extractFrame ffmpeg로 영상의 한 프레임을 PNG로 저장. at이 비어있으면 첫 프레임
+func extractFrame(videoURL, outPath, at string) error {
+	// ffmpeg로 썸네일 생성 (bash에서 성공했던 방식과 동일)
+	args := []string{"-i", videoURL, "-vframes", "1", "-f", "image2", outPath, "-y"}
+	if at != "" {
+		args = append([]string{"-ss", at}, args...)
+	}
+	cmd := exec.Command("ffmpeg", args...)
+
+	// 에러 출력 캡처
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("썸네일 생성 실패: %w", newToolError("ffmpeg", err, output))
+	}
+	return nil
+}
+
+// createAndUploadThumbnail 영상의 한 프레임으로 썸네일을 만들어 업로드. at이 비어있으면 첫 프레임
+func (p *Parser) createAndUploadThumbnail(videoURL, s3Path, at string) error {
+	// 임시 파일명 생성
+	// 섹션을 동시에 처리할 때 겹치지 않도록 UUID 사용
+	tempFile := fmt.Sprintf("/tmp/thumbnail_%s.png", uuid.New().String())
+	defer func() {
+		_ = os.Remove(tempFile)
+	}()
+
+	// 경로 검증 및 ffmpeg 실행을 위한 안전한 경로
+	cleanPath, err := ValidateTempPath(tempFile)
+	if err != nil {
+		return err
+	}
+
+	release := p.acquireProbe()
+	err = extractFrame(videoURL, cleanPath, at)
+	release()
+	if err != nil {
+		return err
+	}
+
+	// S3에 업로드
 	fileHandle, err := SafeOpenFile(cleanPath)
 	if err != nil {
 		return fmt.Errorf("썸네일 파일 열기 실패 -> %w", err)
@@ -900,15 +2595,155 @@ func (p *Parser) createAndUploadThumbnail(videoURL, s3Path string) error {
 		_ = fileHandle.Close()
 	}()
 
+	releaseUpload := p.acquireUpload()
+	defer releaseUpload()
+
 	_, err = p.s3Client.PutObject(p.ctx, &s3.PutObjectInput{
-		Bucket: aws.String(p.bucketName),
-		Key:    aws.String(s3Path),
-		Body:   fileHandle,
+		Bucket:      aws.String(p.thumbnailBucket),
+		Key:         aws.String(s3Path),
+		Body:        fileHandle,
+		ContentType: aws.String("image/png"), // 없으면 octet-stream으로 서빙되어 브라우저가 다운로드함
 	})
 
 	return err
 }
 
+// transcodedKey .mov 키에 대응하는 -transcode 결과 .mp4 키 (.mov가 아니면 false)
+func transcodedKey(s3Path string) (string, bool) {
+	ext := path.Ext(s3Path)
+	if !strings.EqualFold(ext, ".mov") {
+		return "", false
+	}
+	return strings.TrimSuffix(s3Path, ext) + ".mp4", true
+}
+
+// transcodeToMP4 영상을 H.264/AAC .mp4로 변환해 mp4Key에 업로드
+// 이전 실행에서 이미 변환해 둔 객체가 있으면 다시 변환하지 않음
+func (p *Parser) transcodeToMP4(videoURL, mp4Key string) error {
+	exists, err := p.objectExists(mp4Key)
+	if err != nil {
+		return fmt.Errorf("mp4 객체 확인 실패 -> %w", err)
+	}
+	if exists {
+		loglevel.Infof("이미 변환된 mp4 사용: %s", mp4Key)
+		return nil
+	}
+
+	tempFile := fmt.Sprintf("/tmp/transcode_%s.mp4", uuid.New().String())
+	defer func() {
+		_ = os.Remove(tempFile)
+	}()
+
+	cleanPath, err := ValidateTempPath(tempFile)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("ffmpeg", "-i", videoURL,
+		"-c:v", "libx264", "-preset", "veryfast", "-crf", "20",
+		"-c:a", "aac", "-movflags", "+faststart", "-y", cleanPath)
+
+	release := p.acquireTranscode()
+	output, err := cmd.CombinedOutput()
+	release()
+	if err != nil {
+		return fmt.Errorf("mp4 변환 실패: %w", newToolError("ffmpeg", err, output))
+	}
+
+	fileHandle, err := SafeOpenFile(cleanPath)
+	if err != nil {
+		return fmt.Errorf("변환 파일 열기 실패 -> %w", err)
+	}
+	defer func() {
+		_ = fileHandle.Close()
+	}()
+
+	releaseUpload := p.acquireUpload()
+	defer releaseUpload()
+
+	_, err = p.s3Client.PutObject(p.ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(p.bucketName),
+		Key:         aws.String(mp4Key),
+		Body:        fileHandle,
+		ContentType: aws.String("video/mp4"),
+	})
+	if err != nil {
+		return fmt.Errorf("mp4 업로드 실패 -> %w", err)
+	}
+
+	loglevel.Infof("mp4 변환 업로드 완료: %s", mp4Key)
+	return nil
+}
+
+// readURL ffprobe/ffmpeg/MD5가 S3 키의 영상을 읽을 URL (요청은 보내지 않음)
+// 기본은 CloudFront URL, -probe-via-s3이면 presignExpiry 동안 유효한 S3 presigned GET URL
+// 실제로 영상을 읽기 직전에 resolveVideoURL로 리다이렉트를 확인할 것
+func (p *Parser) readURL(s3Path string) (string, error) {
+	if !p.probeViaS3 {
+		return fmt.Sprintf("%s/%s", cloudfrontBaseURL, urlPathEncode(s3Path)), nil
+	}
+
+	req, err := p.presignClient.PresignGetObject(p.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(s3Path),
+	}, s3.WithPresignExpires(presignExpiry))
+	if err != nil {
+		return "", fmt.Errorf("presigned URL 생성 실패 (%s) -> %w", s3Path, err)
+	}
+	return req.URL, nil
+}
+
+// resolveVideoURL readURL의 URL을 영상을 읽기 직전에 한 번 확인해, 같은 파일의 MD5/ffprobe/ffmpeg가 같은 최종 URL을 읽도록 함
+// 기존 콘텐츠라 건너뛰는 파일에는 HEAD 요청을 보내지 않도록 호출하는 곳에서 필요할 때만 부름
+// presigned URL은 서명이 GET에 묶여 있어 HEAD가 403이 되고 S3는 리다이렉트하지 않으므로 그대로 사용
+func (p *Parser) resolveVideoURL(videoURL string) string {
+	if p.probeViaS3 {
+		return videoURL
+	}
+	return p.resolveRedirects(videoURL)
+}
+
+// resolveRedirects HEAD 요청으로 리다이렉트(예: CDN의 리전 엣지로의 301)를 따라간 최종 URL을 반환
+// Go의 HTTP 클라이언트와 달리 ffprobe/ffmpeg는 리다이렉트를 따라가지 않을 수 있어 MD5와 결과가 달라지는 것을 막음
+// 확인에 실패하면 원래 URL을 그대로 사용 (이후 단계에서 오류로 보고됨)
+func (p *Parser) resolveRedirects(rawURL string) string {
+	p.cdnLimiter.Wait()
+
+	ctx, cancel := context.WithTimeout(p.ctx, httpHeaderTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return rawURL
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		loglevel.Warnf("리다이렉트 확인 실패, 원래 URL 사용: %v", err)
+		return rawURL
+	}
+	_ = resp.Body.Close()
+
+	finalURL := resp.Request.URL.String()
+	if finalURL != rawURL {
+		loglevel.Infof("리다이렉트된 URL 사용: %s -> %s", rawURL, finalURL)
+	}
+	return finalURL
+}
+
+// storedURL DB에 저장할 S3 키의 URL (-stored-base-url 반영)
+func (p *Parser) storedURL(s3Path string) string {
+	return fmt.Sprintf("%s/%s", p.storedBaseURL, urlPathEncode(s3Path))
+}
+
+// thumbnailLocation 영상 S3 키에 대응하는 썸네일 S3 키와 thumbnail_url
+// 기본값은 영상 옆의 <영상 키>_thumbnail.png, -thumbnail-prefix가 있으면 그 아래에 같은 경로로 둠
+func (p *Parser) thumbnailLocation(videoKey string) (string, string) {
+	key := strings.TrimSuffix(videoKey, path.Ext(videoKey)) + "_thumbnail.png"
+	if p.thumbnailPrefix != "" {
+		key = p.thumbnailPrefix + "/" + key
+	}
+	return key, fmt.Sprintf("%s/%s", p.thumbnailBaseURL, urlPathEncode(key))
+}
+
 // 유틸리티 함수들
 func (p *Parser) getModuleType(moduleName string) string {
 	if strings.Contains(moduleName, "개념") {
@@ -918,25 +2753,123 @@ func (p *Parser) getModuleType(moduleName string) string {
 	} else if strings.Contains(moduleName, "시험") {
 		return "exam"
 	}
+
+	// 판별 불가 모듈은 -default-module-type으로 대체 (감사용으로 모듈마다 로그)
+	if p.defaultModuleType != "" {
+		loglevel.Warnf("⚠️  모듈 타입 판별 불가, 기본 타입 사용: %s -> %s", moduleName, p.defaultModuleType)
+		return p.defaultModuleType
+	}
 	return "unknown"
 }
 
-// URL에서 MD5 해시 계산
-func calculateURLMD5(url string) (string, error) {
-	resp, err := http.Get(url) //nolint:gosec
-	if err != nil {
-		return "", err
+// CloudFront 다운로드 타임아웃. 영상 크기가 제각각이라 전체 시간 대신
+// 연결/응답 헤더/읽기 사이 간격을 제한함
+const (
+	httpConnectTimeout = 10 * time.Second
+	httpHeaderTimeout  = 30 * time.Second
+	httpReadTimeout    = 60 * time.Second
+
+	httpMaxAttempts = 3
+	httpRetryDelay  = 2 * time.Second
+)
+
+// httpClient 모든 다운로드가 공유하는 클라이언트 (연결 재사용)
+var httpClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: httpConnectTimeout, KeepAlive: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   httpConnectTimeout,
+		ResponseHeaderTimeout: httpHeaderTimeout,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConnsPerHost:   16,
+	},
+}
+
+// httpStatusError 재시도해도 소용없는 HTTP 응답 (4xx)
+type httpStatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("GET %s: HTTP %d", e.URL, e.StatusCode)
+}
+
+// withHTTPRetry 일시적인 오류(네트워크, 5xx)면 지연을 늘려가며 재시도. ctx가 취소되면 즉시 중단
+func withHTTPRetry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= httpMaxAttempts; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode < 500 {
+			return err
+		}
+		if ctx.Err() != nil || attempt == httpMaxAttempts {
+			break
+		}
+		loglevel.Warnf("HTTP 요청 실패 (%d/%d), 재시도: %v", attempt, httpMaxAttempts, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(httpRetryDelay * time.Duration(attempt)):
+		}
 	}
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+	return err
+}
+
+// idleTimeoutReader 일정 시간 동안 데이터가 오지 않으면 요청을 취소하는 Reader
+type idleTimeoutReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *idleTimeoutReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.timer.Reset(r.timeout)
+	return n, err
+}
+
+// URL에서 MD5 해시 계산
+func calculateURLMD5(ctx context.Context, url string) (string, error) {
+	var sum string
+	err := withHTTPRetry(ctx, func() error {
+		reqCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode != http.StatusOK {
+			return &httpStatusError{URL: url, StatusCode: resp.StatusCode}
+		}
+
+		// 읽기가 멈추면 요청을 취소해 io.Copy가 무한 대기하지 않도록 함
+		timer := time.AfterFunc(httpReadTimeout, cancel)
+		defer timer.Stop()
 
-	hash := md5.New() //nolint:gosec
-	if _, err := io.Copy(hash, resp.Body); err != nil {
+		hash := md5.New() //nolint:gosec
+		if _, err := io.Copy(hash, &idleTimeoutReader{r: resp.Body, timer: timer, timeout: httpReadTimeout}); err != nil {
+			return err
+		}
+		sum = fmt.Sprintf("%x", hash.Sum(nil))
+		return nil
+	})
+	if err != nil {
 		return "", err
 	}
-
-	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+	return sum, nil
 }
 
 // URL 경로 인코딩 함수 - 한글은 유지하고 띄어쓰기와 주요 특수문자만 인코딩
@@ -951,18 +2884,192 @@ func urlPathEncode(urlPath string) string {
 	return result
 }
 
+// urlPathDecode urlPathEncode의 역변환 - URL 경로를 S3 키로 복원
+func urlPathDecode(urlPath string) string {
+	result := strings.ReplaceAll(urlPath, "%20", " ")
+	result = strings.ReplaceAll(result, "%2B", "+")
+	result = strings.ReplaceAll(result, "%3D", "=")
+	result = strings.ReplaceAll(result, "%26", "&")
+	result = strings.ReplaceAll(result, "%23", "#")
+	result = strings.ReplaceAll(result, "%3F", "?")
+	return result
+}
+
 func checkCommand(cmd string, args ...string) error {
 	command := exec.Command(cmd, args...)
 	return command.Run()
 }
 
-func getVideoDuration(videoURL string) (int, error) {
-	cmd := exec.Command("ffprobe", "-v", "quiet", "-show_entries", "format=duration", "-of", "csv=p=0", videoURL)
+// probeDuration 영상 길이 추출. -save-probe-dir이 있으면 ffprobe 전체 JSON을 한 번만 받아 저장하고 길이도 거기서 읽음
+// -probe-source가 format이 아니면 스트림 길이도 필요하므로 항상 전체 JSON을 받음
+func (p *Parser) probeDuration(videoURL, s3Path string) (int, error) {
+	if p.saveProbeDir == "" && p.probeSource == "format" {
+		return getVideoDuration(videoURL)
+	}
+
+	cmd := exec.Command("ffprobe", "-v", "error", "-print_format", "json", "-show_format", "-show_streams", videoURL)
 	output, err := cmd.Output()
 	if err != nil {
+		return 0, newToolError("ffprobe", err, nil)
+	}
+
+	if p.saveProbeDir != "" {
+		if err := saveProbeOutput(p.saveProbeDir, p.outputRoot, s3Path, output); err != nil {
+			loglevel.Warnf("ffprobe 출력 저장 실패: %v", err)
+		}
+	}
+
+	return parseProbeDuration(output, p.probeSource)
+}
+
+// parseProbeDuration ffprobe JSON에서 source에 맞는 길이(초)를 읽음
+// format: 컨테이너 길이, stream: 첫 비디오 스트림 길이, max: 둘 중 큰 값
+// 한쪽 값이 없으면 있는 값을 사용
+func parseProbeDuration(output []byte, source string) (int, error) {
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			Duration  string `json:"duration"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
 		return 0, err
 	}
 
+	formatDuration, formatErr := strconv.ParseFloat(probe.Format.Duration, 64)
+	streamDuration, streamErr := 0.0, errors.New("비디오 스트림 길이 없음")
+	for _, stream := range probe.Streams {
+		if stream.CodecType == "video" {
+			streamDuration, streamErr = strconv.ParseFloat(stream.Duration, 64)
+			break
+		}
+	}
+
+	if formatErr != nil && streamErr != nil {
+		return 0, formatErr
+	}
+	if formatErr != nil {
+		return int(streamDuration), nil
+	}
+	if streamErr != nil {
+		return int(formatDuration), nil
+	}
+
+	switch source {
+	case "stream":
+		return int(streamDuration), nil
+	case "max":
+		return int(max(formatDuration, streamDuration)), nil
+	default:
+		return int(formatDuration), nil
+	}
+}
+
+// saveProbeOutput ffprobe 출력을 dir/<S3 키>.probe.json으로 저장
+func saveProbeOutput(dir, root, s3Path string, output []byte) error {
+	// 상대 경로 공격 방지 (filepath.Join이 ..을 정리해 버리기 전에 확인)
+	if hasParentRef(s3Path) {
+		return errors.New("invalid file path: relative path not allowed")
+	}
+
+	outPath, err := cleanOutputPath(filepath.Join(dir, filepath.FromSlash(s3Path)+".probe.json"), root)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(outPath), 0o750); err != nil {
+		return err
+	}
+	return SafeWriteFile(outPath, root, output)
+}
+
+// ffprobe/ffmpeg 실패 분류 (운영자가 무엇을 고쳐야 하는지 구분하기 위함)
+const (
+	toolErrNetwork = "network"  // URL 접근 실패 (403/404, DNS, 연결, 타임아웃) → S3/CloudFront 권한·주소 확인
+	toolErrCodec   = "codec"    // 원본 영상 문제 (손상, 지원하지 않는 코덱) → 원본 파일 확인
+	toolErrLocalIO = "local-io" // 작업 서버 문제 (디스크 공간, 파일 권한, ffmpeg 미설치) → 작업 서버 확인
+	toolErrUnknown = "unknown"
+)
+
+// toolErrorPatterns stderr에 포함된 문구(소문자)로 분류. 앞쪽 분류가 우선
+// (403 응답 본문을 영상으로 읽으면 "invalid data"도 함께 나오므로 network를 먼저 확인)
+var toolErrorPatterns = []struct {
+	category string
+	patterns []string
+}{
+	{toolErrNetwork, []string{
+		"server returned", "http error", "connection refused", "connection reset", "connection timed out",
+		"operation timed out", "name or service not known", "failed to resolve", "could not resolve",
+		"network is unreachable", "no route to host", "tls handshake", "ssl routines", "gnutls error",
+		"certificate verify failed",
+	}},
+	{toolErrCodec, []string{
+		"invalid data found", "moov atom not found", "could not find codec", "unsupported codec",
+		"decoder not found", "unknown decoder", "unknown encoder", "error while decoding",
+		"does not contain any stream", "could not find tag for codec", "invalid nal unit",
+	}},
+	{toolErrLocalIO, []string{
+		"no space left", "permission denied", "read-only file system", "disk quota",
+		"could not open file", "error writing", "too many open files", "cannot allocate memory",
+	}},
+}
+
+// classifyToolStderr ffprobe/ffmpeg stderr를 network, codec, local-io, unknown 중 하나로 분류
+func classifyToolStderr(stderr string) string {
+	lower := strings.ToLower(stderr)
+	for _, group := range toolErrorPatterns {
+		for _, pattern := range group.patterns {
+			if strings.Contains(lower, pattern) {
+				return group.category
+			}
+		}
+	}
+	return toolErrUnknown
+}
+
+// toolError 분류된 ffprobe/ffmpeg 실행 실패
+type toolError struct {
+	Tool     string
+	Category string
+	Stderr   string
+	Err      error
+}
+
+func (e *toolError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("%s 실패 [%s]: %v", e.Tool, e.Category, e.Err)
+	}
+	return fmt.Sprintf("%s 실패 [%s]: %v, 출력: %s", e.Tool, e.Category, e.Err, e.Stderr)
+}
+
+func (e *toolError) Unwrap() error {
+	return e.Err
+}
+
+// newToolError 실행 실패를 분류. output이 nil이면 cmd.Output()이 ExitError에 담은 stderr를 사용
+func newToolError(tool string, err error, output []byte) error {
+	var exitErr *exec.ExitError
+	if output == nil && errors.As(err, &exitErr) {
+		output = exitErr.Stderr
+	}
+	stderr := strings.TrimSpace(string(output))
+
+	category := classifyToolStderr(stderr)
+	if errors.Is(err, exec.ErrNotFound) {
+		category = toolErrLocalIO
+	}
+	return &toolError{Tool: tool, Category: category, Stderr: stderr, Err: err}
+}
+
+func getVideoDuration(videoURL string) (int, error) {
+	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "csv=p=0", videoURL)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, newToolError("ffprobe", err, nil)
+	}
+
 	durationStr := strings.TrimSpace(string(output))
 	duration, err := strconv.ParseFloat(durationStr, 64)
 	if err != nil {
@@ -982,6 +3089,16 @@ func extractSequence(name string) int {
 	return 0
 }
 
+// fileSequence 섹션의 index번째(0부터) 파일의 콘텐츠 sequence
+// sequence 정렬은 파일명 앞 번호, key 정렬은 S3 키 순서(1부터)만 사용
+// (key 정렬에서 번호 있는 파일의 번호와 섞으면 번호 없는 파일의 순서가 1_, 2_ 파일의 번호와 겹칠 수 있음)
+func fileSequence(filename string, index int, sortMode string) int {
+	if sortMode == "key" {
+		return index + 1
+	}
+	return extractSequence(filename)
+}
+
 func extractSequenceWithIndex(name string, index int) int {
 	// 먼저 이름에서 숫자 추출 시도
 	seq := extractSequence(name)
@@ -1002,6 +3119,43 @@ func extractTitle(filename string) string {
 	return filename
 }
 
+// trimName 앞뒤 공백과 보이지 않는 문자(zero-width space 등) 제거
+// S3 키에는 원래 이름을 그대로 쓰고, DB에 저장할 제목에만 사용
+func trimName(name string) string {
+	return strings.TrimFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.Is(unicode.Cf, r)
+	})
+}
+
+func extractModuleTitle(name string) string {
+	// 모듈명에서 sequence 번호와 타입 제거 (예: "0_개념_점과 좌표" -> "점과 좌표")
+	baseName := name
+
+	// 먼저 앞의 숫자_ 부분 제거
+	re := regexp.MustCompile(`^\d+_`)
+	baseName = re.ReplaceAllString(baseName, "")
+
+	// 그다음 타입 제거
+	if strings.Contains(baseName, "개념_") {
+		baseName = strings.Replace(baseName, "개념_", "", 1)
+	} else if strings.Contains(baseName, "유형_") {
+		baseName = strings.Replace(baseName, "유형_", "", 1)
+	} else if strings.Contains(baseName, "시험_") {
+		baseName = strings.Replace(baseName, "시험_", "", 1)
+	}
+	return baseName
+}
+
+// renderTitle 제목 템플릿의 {module}, {section}, {filename}, {n}을 치환
+func renderTitle(template, module, section, filename string, n int) string {
+	return strings.NewReplacer(
+		"{module}", module,
+		"{section}", section,
+		"{filename}", filename,
+		"{n}", strconv.Itoa(n),
+	).Replace(template)
+}
+
 func extractSectionTitle(name string) string {
 	// 0_섹션명 -> 섹션명
 	re := regexp.MustCompile(`^\d+_(.+)$`)
@@ -1012,6 +3166,20 @@ func extractSectionTitle(name string) string {
 	return name
 }
 
+// classifyFile 파일명이 강의(N_제목.ext) 또는 해설(..._해설_ID.ext) 규칙에 맞으면 "lecture"/"solution", 아니면 ""
+func classifyFile(filename string) string {
+	if isSolutionFile(filename) {
+		if extractExerciseRefID(filename) != "" {
+			return "solution"
+		}
+		return ""
+	}
+	if regexp.MustCompile(`^\d+_(.+)\.(mov|mp4)$`).MatchString(filename) {
+		return "lecture"
+	}
+	return ""
+}
+
 func isSolutionFile(filename string) bool {
 	return strings.Contains(filename, "해설")
 }
@@ -1065,7 +3233,7 @@ func generateExerciseTitle(exerciseType string, exerciseNumber int) string {
 
 func SafeOpenFile(filename string) (*os.File, error) {
 	// 상대 경로 공격 방지
-	if strings.Contains(filename, "..") {
+	if hasParentRef(filename) {
 		return nil, errors.New("invalid file path: relative path not allowed")
 	}
 
@@ -1075,10 +3243,58 @@ func SafeOpenFile(filename string) (*os.File, error) {
 	return os.Open(cleanPath)
 }
 
+// SafeWriteFile cleanOutputPath로 검증한 경로에 파일을 씀 (없으면 생성, 있으면 덮어씀)
+func SafeWriteFile(filename, root string, data []byte) error {
+	cleanPath, err := cleanOutputPath(filename, root)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cleanPath, data, 0o600)
+}
+
+// cleanOutputPath 출력 파일 경로를 검증하고 정리된 경로를 반환
+// ".." 구성 요소가 있으면 거부하고, root가 비어있지 않으면 root 하위 경로만 허용 (-output-root)
+func cleanOutputPath(filename, root string) (string, error) {
+	// 상대 경로 공격 방지
+	if hasParentRef(filename) {
+		return "", errors.New("invalid file path: relative path not allowed")
+	}
+
+	cleanPath := filepath.Clean(filename)
+	if root == "" {
+		return cleanPath, nil
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid output-root %s: %w", root, err)
+	}
+	absPath, err := filepath.Abs(cleanPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid file path %s: %w", filename, err)
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid file path: %s is outside of %s", filename, root)
+	}
+	return cleanPath, nil
+}
+
+// hasParentRef 경로에 ".." 구성 요소가 있으면 true
+// S3 키에서 온 a..b.mov 같은 이름은 상위 디렉토리를 가리키지 않으므로 허용
+func hasParentRef(filename string) bool {
+	for _, part := range strings.FieldsFunc(filename, func(r rune) bool { return r == '/' || r == filepath.Separator }) {
+		if part == ".." {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateTempPath 임시 파일 경로 검증 - /tmp 디렉토리만 허용
 func ValidateTempPath(filename string) (string, error) {
 	// 상대 경로 공격 방지
-	if strings.Contains(filename, "..") {
+	if hasParentRef(filename) {
 		return "", errors.New("invalid file path: relative path not allowed")
 	}
 
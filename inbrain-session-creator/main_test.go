@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTool PATH 앞에 name 이름의 셸 스크립트를 두고, 호출 횟수를 세는 함수를 반환
+// body는 스크립트 본문 (예: stderr 출력 후 exit 1)
+func fakeTool(t testing.TB, name, body string) func() int {
+	t.Helper()
+	dir := t.TempDir()
+	calls := filepath.Join(dir, name+".calls")
+	script := "#!/bin/sh\necho x >> '" + calls + "'\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	return func() int {
+		data, err := os.ReadFile(calls)
+		if err != nil {
+			return 0
+		}
+		return strings.Count(string(data), "x")
+	}
+}
+
+// newTestParser DB/S3 없이 파일 단위 처리를 실행할 수 있는 Parser (testExam이라 MD5/DB 확인 없음)
+func newTestParser() *Parser {
+	return &Parser{
+		ctx:          context.Background(),
+		testExam:     true,
+		probeSource:  "format",
+		probeSem:     make(chan struct{}, 1),
+		uploadSem:    make(chan struct{}, 1),
+		transcodeSem: make(chan struct{}, transcodeConcurrency),
+		progress:     newProgressTracker(),
+	}
+}
+
+func TestCreateVideoWithRetryQuarantinesProbeFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		wantCalls  int
+	}{
+		{"no retries", 0, 1},
+		{"two retries", 2, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := fakeTool(t, "ffprobe", "echo 'moov atom not found' >&2\nexit 1")
+
+			p := newTestParser()
+			p.maxRetriesPerFile = tt.maxRetries
+			p.fileRetryDelay = time.Millisecond
+
+			const key = "session/module/1_broken.mp4"
+			id, err := p.createVideoWithRetry("broken", "https://media.example/broken.mp4", key)
+			if err == nil || id != 0 {
+				t.Fatalf("createVideoWithRetry = %d, %v; want error", id, err)
+			}
+			if got := calls(); got != tt.wantCalls {
+				t.Errorf("ffprobe called %d times, want %d", got, tt.wantCalls)
+			}
+			if len(p.failedFiles) != 1 || p.failedFiles[0].S3Path != key {
+				t.Errorf("failedFiles = %+v, want only %s", p.failedFiles, key)
+			}
+			if !p.failedKeys[key] {
+				t.Errorf("failedKeys missing %s", key)
+			}
+		})
+	}
+}
+
+func TestDumpFailedURLsIncludesProbeFailures(t *testing.T) {
+	fakeTool(t, "ffprobe", "echo 'Invalid data found when processing input' >&2\nexit 1")
+
+	p := newTestParser()
+	p.fileRetryDelay = time.Millisecond
+
+	// ffprobe 실패(격리)와 다른 단계의 실패가 함께 기록되고, 같은 키는 한 번만 나옴
+	if _, err := p.createVideoWithRetry("깨진 영상", "https://media.example/x.mp4", "세션/모듈/2_깨진 영상.mp4"); err == nil {
+		t.Fatal("createVideoWithRetry succeeded, want ffprobe failure")
+	}
+	p.markFailed("세션/모듈/1_강의.mp4")
+	p.markFailed("세션/모듈/1_강의.mp4")
+
+	out := filepath.Join(t.TempDir(), "failed.txt")
+	if err := p.DumpFailedURLs(out); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cloudfrontBaseURL + "/세션/모듈/1_강의.mp4\n" +
+		cloudfrontBaseURL + "/세션/모듈/2_깨진%20영상.mp4\n"
+	if string(data) != want {
+		t.Errorf("dump =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestClassifyToolStderr(t *testing.T) {
+	tests := []struct {
+		name   string
+		stderr string
+		want   string
+	}{
+		{"cloudfront 403", "[https @ 0x55d0] HTTP error 403 Forbidden\nhttps://media.example/a.mp4: Server returned 403 Forbidden (access denied)", toolErrNetwork},
+		{"403 body read as video", "Server returned 403 Forbidden (access denied)\nInvalid data found when processing input", toolErrNetwork},
+		{"dns", "[tcp @ 0x1] Failed to resolve hostname media.example: Name or service not known", toolErrNetwork},
+		{"connection refused", "[tcp @ 0x1] Connection to tcp://127.0.0.1:443 failed: Connection refused", toolErrNetwork},
+		{"timeout", "[tcp @ 0x1] Connection to tcp://media.example:443 failed: Connection timed out", toolErrNetwork},
+		{"tls", "[tls @ 0x1] error:0A000086:SSL routines::certificate verify failed", toolErrNetwork},
+		{"truncated mp4", "[mov,mp4,m4a,3gp,3g2,mj2 @ 0x1] moov atom not found\na.mp4: Invalid data found when processing input", toolErrCodec},
+		{"unknown fourcc", "Could not find codec parameters for stream 0 (Video: none (tlsx / 0x78736C74), none): unknown codec", toolErrCodec},
+		{"no video stream", "Output file #0 does not contain any stream", toolErrCodec},
+		{"disk full", "av_interleaved_write_frame(): No space left on device\nError writing trailer of /tmp/t.mp4", toolErrLocalIO},
+		{"tmp not writable", "/tmp/thumbnail_1.png: Permission denied", toolErrLocalIO},
+		{"read-only fs", "[image2 @ 0x1] Could not open file : /tmp/t.png\nRead-only file system", toolErrLocalIO},
+		{"empty", "", toolErrUnknown},
+		{"unrecognized", "Conversion failed!", toolErrUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyToolStderr(tt.stderr); got != tt.want {
+				t.Errorf("classifyToolStderr(%q) = %s, want %s", tt.stderr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolFailuresReachFailedFiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		ffprobe      string
+		ffmpeg       string
+		wantCategory string
+		wantFallback bool // 격리하지 않고 경고 후 비디오를 만듦
+	}{
+		{
+			name:         "probe codec error",
+			ffprobe:      "echo 'moov atom not found' >&2\nexit 1",
+			wantCategory: toolErrCodec,
+		},
+		{
+			name:         "thumbnail network error",
+			ffprobe:      "echo 12.5",
+			ffmpeg:       "echo 'Server returned 403 Forbidden (access denied)' >&2\nexit 1",
+			wantCategory: toolErrNetwork,
+			wantFallback: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeTool(t, "ffprobe", tt.ffprobe)
+			if tt.ffmpeg != "" {
+				fakeTool(t, "ffmpeg", tt.ffmpeg)
+			}
+
+			db, _ := openFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				if strings.Contains(query, "INSERT INTO videos") {
+					return rows(row(int64(7))), nil
+				}
+				return nil, nil
+			})
+
+			p := newTestParser()
+			p.db = db
+			p.forceThumbnails = true // 기존 썸네일 확인(S3) 없이 바로 ffmpeg 실행
+			id, err := p.createVideoWithRetry("영상", "https://media.example/a.mp4", "s/a.mp4")
+			if tt.wantFallback && (err != nil || id != 7) {
+				t.Fatalf("createVideoWithRetry = %d, %v; want the video created despite the thumbnail failure", id, err)
+			}
+			if !tt.wantFallback && err == nil {
+				t.Fatal("createVideoWithRetry succeeded, want error")
+			}
+
+			if len(p.failedFiles) != 1 {
+				t.Fatalf("failedFiles = %+v, want 1 entry", p.failedFiles)
+			}
+			if p.failedFiles[0].Fallback != tt.wantFallback {
+				t.Errorf("Fallback = %v, want %v", p.failedFiles[0].Fallback, tt.wantFallback)
+			}
+			if !p.failedKeys["s/a.mp4"] {
+				t.Error("failedKeys missing s/a.mp4")
+			}
+			var toolErr *toolError
+			if !errors.As(p.failedFiles[0].Err, &toolErr) {
+				t.Fatalf("failed file error %v is not a toolError", p.failedFiles[0].Err)
+			}
+			if toolErr.Category != tt.wantCategory {
+				t.Errorf("category = %s, want %s", toolErr.Category, tt.wantCategory)
+			}
+			if !strings.Contains(p.failedFiles[0].Err.Error(), "["+tt.wantCategory+"]") {
+				t.Errorf("error %q does not show the category", p.failedFiles[0].Err)
+			}
+		})
+	}
+}
+
+func TestRateLimiterStop(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter *rateLimiter
+	}{
+		{"nil limiter", nil},
+		{"running limiter", newRateLimiter(1000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.limiter.Wait()
+			tt.limiter.Stop()
+			if tt.limiter != nil {
+				select {
+				case <-tt.limiter.ticker.C:
+					t.Fatal("ticker still ticking after Stop")
+				case <-time.After(20 * time.Millisecond):
+				}
+			}
+
+			// Close는 -cloudfront-rps 없이 만든 Parser(limiter nil)에서도 안전해야 함
+			p := newTestParser()
+			p.cdnLimiter = tt.limiter
+			p.Close()
+		})
+	}
+}
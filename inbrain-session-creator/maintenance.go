@@ -0,0 +1,746 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/unboxerscorp/utility/common/loglevel"
+)
+
+// 유지보수 명령어들 - S3를 스캔하지 않고 DB만 사용
+
+type orphanVideo struct {
+	ID        int64
+	Title     string
+	SourceURL string
+}
+
+// CheckOrphanVideos 어떤 강의/연습문제에서도 참조하지 않는 비디오를 찾아 출력하고, deleteOrphans가 true이면 soft delete
+func (p *Parser) CheckOrphanVideos(deleteOrphans bool, batchSize int) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch-size는 1 이상이어야 합니다")
+	}
+
+	// 1. 참조되지 않는 비디오 목록 조회 (id 커서 기반 배치)
+	query := `
+		SELECT v.id, v.title, v.source_url
+		FROM videos v
+		WHERE v.deleted_at IS NULL
+		  AND v.id > $1
+		  AND NOT EXISTS (SELECT 1 FROM lectures l WHERE l.lecture_video_id = v.id)
+		  AND NOT EXISTS (SELECT 1 FROM exercises e WHERE e.solution_video_id = v.id)
+		ORDER BY v.id
+		LIMIT $2`
+
+	var orphans []orphanVideo
+	var lastID int64
+	for {
+		rows, err := p.db.Query(query, lastID, batchSize)
+		if err != nil {
+			return fmt.Errorf("고아 비디오 조회 실패 -> %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var v orphanVideo
+			if err := rows.Scan(&v.ID, &v.Title, &v.SourceURL); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("고아 비디오 스캔 실패 -> %w", err)
+			}
+			orphans = append(orphans, v)
+			lastID = v.ID
+			fetched++
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("고아 비디오 조회 실패 -> %w", err)
+		}
+
+		if fetched < batchSize {
+			break
+		}
+	}
+
+	// 2. 목록 출력 (삭제 전에 항상 먼저 출력)
+	fmt.Println("=== 참조되지 않는 비디오 ===")
+	for _, v := range orphans {
+		fmt.Printf("  - ID %d: %s (%s)\n", v.ID, v.Title, v.SourceURL)
+	}
+	fmt.Printf("총 %d개\n", len(orphans))
+
+	if !deleteOrphans {
+		if len(orphans) > 0 {
+			fmt.Println("삭제하려면 -delete 옵션을 추가하세요")
+		}
+		return nil
+	}
+
+	// 3. 배치 단위로 soft delete (참조 여부를 다시 확인하여 그 사이 생긴 참조는 보호)
+	deleteQuery := `
+		UPDATE videos v SET deleted_at = NOW()
+		WHERE v.id = ANY($1)
+		  AND v.deleted_at IS NULL
+		  AND NOT EXISTS (SELECT 1 FROM lectures l WHERE l.lecture_video_id = v.id)
+		  AND NOT EXISTS (SELECT 1 FROM exercises e WHERE e.solution_video_id = v.id)`
+
+	var deleted int64
+	for i := 0; i < len(orphans); i += batchSize {
+		end := i + batchSize
+		if end > len(orphans) {
+			end = len(orphans)
+		}
+
+		ids := make([]int64, 0, end-i)
+		for _, v := range orphans[i:end] {
+			ids = append(ids, v.ID)
+		}
+
+		tx, err := p.db.Begin()
+		if err != nil {
+			return fmt.Errorf("트랜잭션 시작 실패 -> %w", err)
+		}
+		result, err := tx.Exec(deleteQuery, pq.Array(ids))
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("고아 비디오 삭제 실패 -> %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("트랜잭션 커밋 실패 -> %w", err)
+		}
+
+		affected, _ := result.RowsAffected()
+		deleted += affected
+		loglevel.Infof("고아 비디오 삭제 진행: %d/%d", end, len(orphans))
+	}
+
+	log.Printf("✅ 고아 비디오 %d개 삭제 완료", deleted)
+	return nil
+}
+
+type orphanContent struct {
+	ID          int64
+	Title       string
+	ContentType string
+	Reason      string
+}
+
+// orphanContentCondition 참조 대상(강의/강의 비디오/연습문제)이 없거나 삭제된 learning_contents 조건 (별칭 lc)
+const orphanContentCondition = `
+		  (lc.content_type = 'lecture' AND (
+		      NOT EXISTS (SELECT 1 FROM lectures l WHERE l.id = lc.lecture_id AND l.deleted_at IS NULL)
+		      OR EXISTS (SELECT 1 FROM lectures l WHERE l.id = lc.lecture_id
+		                 AND NOT EXISTS (SELECT 1 FROM videos v WHERE v.id = l.lecture_video_id AND v.deleted_at IS NULL))))
+		  OR (lc.content_type = 'exercise' AND
+		      NOT EXISTS (SELECT 1 FROM exercises e WHERE e.id = lc.exercise_id AND e.deleted_at IS NULL))`
+
+// CheckOrphanContents 참조하는 강의/강의 비디오/연습문제가 없거나 삭제된 learning_contents를 찾아 출력하고, apply가 true이면 soft delete
+func (p *Parser) CheckOrphanContents(apply bool, batchSize int) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch-size는 1 이상이어야 합니다")
+	}
+
+	// 1. 깨진 콘텐츠 목록 조회 (id 커서 기반 배치)
+	query := `
+		SELECT lc.id, lc.title, lc.content_type,
+		       CASE
+		           WHEN lc.content_type = 'exercise' THEN '연습문제 없음/삭제됨'
+		           WHEN NOT EXISTS (SELECT 1 FROM lectures l WHERE l.id = lc.lecture_id AND l.deleted_at IS NULL) THEN '강의 없음/삭제됨'
+		           ELSE '강의 비디오 없음/삭제됨'
+		       END
+		FROM learning_contents lc
+		WHERE lc.deleted_at IS NULL
+		  AND lc.id > $1
+		  AND (` + orphanContentCondition + `)
+		ORDER BY lc.id
+		LIMIT $2`
+
+	var orphans []orphanContent
+	var lastID int64
+	for {
+		rows, err := p.db.Query(query, lastID, batchSize)
+		if err != nil {
+			return fmt.Errorf("깨진 콘텐츠 조회 실패 -> %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var c orphanContent
+			if err := rows.Scan(&c.ID, &c.Title, &c.ContentType, &c.Reason); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("깨진 콘텐츠 스캔 실패 -> %w", err)
+			}
+			orphans = append(orphans, c)
+			lastID = c.ID
+			fetched++
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("깨진 콘텐츠 조회 실패 -> %w", err)
+		}
+
+		if fetched < batchSize {
+			break
+		}
+	}
+
+	// 2. 목록 출력 (삭제 전에 항상 먼저 출력)
+	fmt.Println("=== 참조 대상이 없거나 삭제된 콘텐츠 ===")
+	for _, c := range orphans {
+		fmt.Printf("  - ID %d: %s [%s] (%s)\n", c.ID, c.Title, c.ContentType, c.Reason)
+	}
+	fmt.Printf("총 %d개\n", len(orphans))
+
+	if !apply {
+		if len(orphans) > 0 {
+			fmt.Println("삭제하려면 -delete 옵션을 추가하세요")
+		}
+		return nil
+	}
+
+	// 3. 배치 단위로 soft delete (조건을 다시 확인하여 그 사이 복구된 참조는 보호)
+	deleteQuery := `
+		UPDATE learning_contents lc SET deleted_at = NOW()
+		WHERE lc.id = ANY($1)
+		  AND lc.deleted_at IS NULL
+		  AND (` + orphanContentCondition + `)`
+
+	var deleted int64
+	for i := 0; i < len(orphans); i += batchSize {
+		end := i + batchSize
+		if end > len(orphans) {
+			end = len(orphans)
+		}
+
+		ids := make([]int64, 0, end-i)
+		for _, c := range orphans[i:end] {
+			ids = append(ids, c.ID)
+		}
+
+		result, err := p.db.Exec(deleteQuery, pq.Array(ids))
+		if err != nil {
+			return fmt.Errorf("깨진 콘텐츠 삭제 실패 -> %w", err)
+		}
+
+		affected, _ := result.RowsAffected()
+		deleted += affected
+		loglevel.Infof("깨진 콘텐츠 삭제 진행: %d/%d", end, len(orphans))
+	}
+
+	log.Printf("✅ 깨진 콘텐츠 %d개 삭제 완료", deleted)
+	return nil
+}
+
+type duplicateVideoGroup struct {
+	MD5Hash string
+	IDs     []int64 // id 오름차순, IDs[0]이 기준 비디오
+}
+
+// MergeDuplicateVideos md5_hash가 같은 비디오를 가장 오래된(id가 가장 작은) 비디오로 병합
+// 강의/연습문제의 참조를 기준 비디오로 옮기고 나머지는 soft delete. apply가 false이면 목록만 출력
+func (p *Parser) MergeDuplicateVideos(apply bool) error {
+	// 1. MD5별 중복 비디오 조회
+	query := `
+		SELECT md5_hash, array_agg(id ORDER BY id)
+		FROM videos
+		WHERE deleted_at IS NULL
+		  AND md5_hash IS NOT NULL
+		  AND md5_hash <> ''
+		GROUP BY md5_hash
+		HAVING COUNT(*) > 1
+		ORDER BY MIN(id)`
+
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return fmt.Errorf("중복 비디오 조회 실패 -> %w", err)
+	}
+
+	var groups []duplicateVideoGroup
+	for rows.Next() {
+		var g duplicateVideoGroup
+		var ids pq.Int64Array
+		if err := rows.Scan(&g.MD5Hash, &ids); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("중복 비디오 스캔 실패 -> %w", err)
+		}
+		g.IDs = ids
+		groups = append(groups, g)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("중복 비디오 조회 실패 -> %w", err)
+	}
+
+	// 2. 목록 출력 (병합 전에 항상 먼저 출력)
+	fmt.Println("=== MD5가 같은 중복 비디오 ===")
+	duplicates := 0
+	for _, g := range groups {
+		fmt.Printf("  - MD5 %s: 기준 ID %d, 중복 %v\n", g.MD5Hash, g.IDs[0], g.IDs[1:])
+		duplicates += len(g.IDs) - 1
+	}
+	fmt.Printf("총 %d개 그룹, 중복 비디오 %d개\n", len(groups), duplicates)
+
+	if !apply {
+		if len(groups) > 0 {
+			fmt.Println("병합하려면 -delete 옵션을 추가하세요")
+		}
+		return nil
+	}
+
+	// 3. MD5 그룹 단위 트랜잭션으로 참조 이동 후 soft delete
+	var lecturesMoved, exercisesMoved, videosDeleted int64
+	for i, g := range groups {
+		canonicalID := g.IDs[0]
+
+		tx, err := p.db.Begin()
+		if err != nil {
+			return fmt.Errorf("트랜잭션 시작 실패 -> %w", err)
+		}
+
+		lectures, exercises, deleted, err := mergeVideosTx(tx, canonicalID, g.IDs[1:])
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%w (MD5 %s)", err, g.MD5Hash)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("트랜잭션 커밋 실패 -> %w", err)
+		}
+
+		lecturesMoved += lectures
+		exercisesMoved += exercises
+		videosDeleted += deleted
+		loglevel.Infof("중복 비디오 병합 진행: %d/%d", i+1, len(groups))
+	}
+
+	log.Printf("✅ 중복 비디오 병합 완료: %d개 그룹, 비디오 %d개 삭제, 강의 %d개 / 연습문제 %d개 참조 이동",
+		len(groups), videosDeleted, lecturesMoved, exercisesMoved)
+	return nil
+}
+
+// mergeVideosTx 강의/연습문제의 duplicateIDs 참조를 canonicalID로 옮기고 duplicateIDs를 soft delete
+func mergeVideosTx(tx *sql.Tx, canonicalID int64, duplicateIDs []int64) (lectures, exercises, deleted int64, err error) {
+	ids := pq.Array(duplicateIDs)
+
+	result, err := tx.Exec(`UPDATE lectures SET lecture_video_id = $1 WHERE lecture_video_id = ANY($2)`, canonicalID, ids)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("강의 비디오 참조 이동 실패 -> %w", err)
+	}
+	lectures, _ = result.RowsAffected()
+
+	result, err = tx.Exec(`UPDATE exercises SET solution_video_id = $1 WHERE solution_video_id = ANY($2)`, canonicalID, ids)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("해설 비디오 참조 이동 실패 -> %w", err)
+	}
+	exercises, _ = result.RowsAffected()
+
+	result, err = tx.Exec(`UPDATE videos SET deleted_at = NOW() WHERE id = ANY($1) AND deleted_at IS NULL`, ids)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("중복 비디오 삭제 실패 -> %w", err)
+	}
+	deleted, _ = result.RowsAffected()
+
+	return lectures, exercises, deleted, nil
+}
+
+type sourceURLDuplicateGroup struct {
+	SourceURL string
+	IDs       []int64 // IDs[0]이 기준 비디오
+}
+
+// DedupeVideosBySourceURL source_url이 같은 비디오를 하나로 병합 (MD5가 없던 시절에 생긴 중복용)
+// 기준 비디오는 md5_hash가 있는 것, 그다음 thumbnail_url이 있는 것, 그다음 id가 가장 작은 것
+// 병합 계획을 먼저 출력하고, apply가 true이면 batchSize개 그룹씩 한 트랜잭션으로 참조 이동 후 soft delete
+func (p *Parser) DedupeVideosBySourceURL(apply bool, batchSize int) error {
+	// 1. source_url별 중복 비디오 조회
+	query := `
+		SELECT source_url,
+		       array_agg(id ORDER BY (COALESCE(md5_hash, '') = ''), (COALESCE(thumbnail_url, '') = ''), id)
+		FROM videos
+		WHERE deleted_at IS NULL
+		  AND source_url IS NOT NULL
+		  AND source_url <> ''
+		GROUP BY source_url
+		HAVING COUNT(*) > 1
+		ORDER BY MIN(id)`
+
+	rows, err := p.db.Query(query)
+	if err != nil {
+		return fmt.Errorf("중복 비디오 조회 실패 -> %w", err)
+	}
+
+	var groups []sourceURLDuplicateGroup
+	for rows.Next() {
+		var g sourceURLDuplicateGroup
+		var ids pq.Int64Array
+		if err := rows.Scan(&g.SourceURL, &ids); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("중복 비디오 스캔 실패 -> %w", err)
+		}
+		g.IDs = ids
+		groups = append(groups, g)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("중복 비디오 조회 실패 -> %w", err)
+	}
+
+	// 2. 병합 계획 출력 (병합 전에 항상 먼저 출력)
+	fmt.Println("=== source_url이 같은 중복 비디오 ===")
+	duplicates := 0
+	for _, g := range groups {
+		fmt.Printf("  - %s: 기준 ID %d, 중복 %v\n", g.SourceURL, g.IDs[0], g.IDs[1:])
+		duplicates += len(g.IDs) - 1
+	}
+	fmt.Printf("총 %d개 그룹, 중복 비디오 %d개\n", len(groups), duplicates)
+
+	if !apply {
+		if len(groups) > 0 {
+			fmt.Println("병합하려면 -delete 옵션을 추가하세요")
+		}
+		return nil
+	}
+
+	// 3. batchSize개 그룹씩 트랜잭션으로 참조 이동 후 soft delete
+	var lecturesMoved, exercisesMoved, videosDeleted int64
+	for start := 0; start < len(groups); start += batchSize {
+		end := start + batchSize
+		if end > len(groups) {
+			end = len(groups)
+		}
+
+		tx, err := p.db.Begin()
+		if err != nil {
+			return fmt.Errorf("트랜잭션 시작 실패 -> %w", err)
+		}
+
+		var batchLectures, batchExercises, batchDeleted int64
+		for _, g := range groups[start:end] {
+			lectures, exercises, deleted, err := mergeVideosTx(tx, g.IDs[0], g.IDs[1:])
+			if err != nil {
+				_ = tx.Rollback()
+				return fmt.Errorf("%w (source_url %s)", err, g.SourceURL)
+			}
+			batchLectures += lectures
+			batchExercises += exercises
+			batchDeleted += deleted
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("트랜잭션 커밋 실패 -> %w", err)
+		}
+
+		lecturesMoved += batchLectures
+		exercisesMoved += batchExercises
+		videosDeleted += batchDeleted
+		loglevel.Infof("source_url 중복 비디오 병합 진행: %d/%d", end, len(groups))
+	}
+
+	log.Printf("✅ source_url 중복 비디오 병합 완료: %d개 그룹, 비디오 %d개 삭제, 강의 %d개 / 연습문제 %d개 참조 이동",
+		len(groups), videosDeleted, lecturesMoved, exercisesMoved)
+	return nil
+}
+
+type videoSource struct {
+	ID        int64
+	SourceURL string
+}
+
+// RegenerateThumbnails 지정한 비디오들의 썸네일을 source_url에서 다시 만들어 업로드하고 thumbnail_url 갱신
+// ids 또는 where 중 하나 이상 필요. id 순서로 배치 처리하며, 배치마다 마지막 ID를 로그로 남겨 -after-id로 재개 가능
+func (p *Parser) RegenerateThumbnails(ids []int64, where, at string, afterID int64, batchSize int) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch-size는 1 이상이어야 합니다")
+	}
+	if len(ids) == 0 && where == "" {
+		return fmt.Errorf("-video-ids 또는 -video-where가 필요합니다")
+	}
+
+	query := `
+		SELECT v.id, v.source_url
+		FROM videos v
+		WHERE v.deleted_at IS NULL
+		  AND v.id > $1`
+	args := []any{afterID, batchSize}
+	if len(ids) > 0 {
+		query += ` AND v.id = ANY($3)`
+		args = append(args, pq.Array(ids))
+	}
+	if where != "" {
+		// 운영자가 직접 입력하는 조건 (유지보수 전용)
+		query += ` AND (` + where + `)`
+	}
+	query += `
+		ORDER BY v.id
+		LIMIT $2`
+
+	var regenerated, failed int
+	lastID := afterID
+	for {
+		args[0] = lastID
+		rows, err := p.db.Query(query, args...)
+		if err != nil {
+			return fmt.Errorf("비디오 조회 실패 -> %w", err)
+		}
+
+		var batch []videoSource
+		for rows.Next() {
+			var v videoSource
+			if err := rows.Scan(&v.ID, &v.SourceURL); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("비디오 스캔 실패 -> %w", err)
+			}
+			batch = append(batch, v)
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("비디오 조회 실패 -> %w", err)
+		}
+
+		for _, v := range batch {
+			if err := p.regenerateThumbnail(v, at); err != nil {
+				loglevel.Warnf("썸네일 재생성 실패: ID %d -> %v", v.ID, err)
+				failed++
+			} else {
+				regenerated++
+			}
+			lastID = v.ID
+		}
+		if len(batch) > 0 {
+			loglevel.Infof("썸네일 재생성 진행: %d개 완료, %d개 실패 (재개: -after-id=%d)", regenerated, failed, lastID)
+		}
+
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	log.Printf("✅ 썸네일 재생성 완료: %d개 성공, %d개 실패", regenerated, failed)
+	return nil
+}
+
+// regenerateThumbnail 비디오 하나의 썸네일을 thumbnailLocation 위치에 다시 만들고 thumbnail_url 갱신
+func (p *Parser) regenerateThumbnail(v videoSource, at string) error {
+	videoKey, ok := p.urlToS3Key(v.SourceURL)
+	if !ok {
+		return fmt.Errorf("CloudFront URL이 아님: %s", v.SourceURL)
+	}
+	thumbnailS3Path, thumbnailURL := p.thumbnailLocation(videoKey)
+
+	// 저장된 URL이 새 CDN을 가리켜도 영상은 기존 CloudFront(-probe-via-s3이면 S3)에서 읽음
+	videoURL, err := p.readURL(videoKey)
+	if err != nil {
+		return err
+	}
+	videoURL = p.resolveVideoURL(videoURL)
+	if err := p.createAndUploadThumbnail(videoURL, thumbnailS3Path, at); err != nil {
+		return err
+	}
+
+	if _, err := p.db.Exec(`UPDATE videos SET thumbnail_url = $1 WHERE id = $2`, thumbnailURL, v.ID); err != nil {
+		return fmt.Errorf("thumbnail_url 갱신 실패 -> %w", err)
+	}
+	return nil
+}
+
+// PreviewThumbnail 썸네일과 같은 방식으로 영상 URL의 한 프레임을 로컬 파일로만 추출하고 경로를 반환
+// (S3 업로드/DB 갱신 없음). outPath가 비어있으면 /tmp에 새 파일을 만들고, 지정하면 root(-output-root) 하위여야 함
+func PreviewThumbnail(videoURL, outPath, root, at string) (string, error) {
+	if !strings.HasPrefix(videoURL, "http://") && !strings.HasPrefix(videoURL, "https://") {
+		return "", fmt.Errorf("http(s) URL이 아님: %s", videoURL)
+	}
+
+	if outPath == "" {
+		outPath = fmt.Sprintf("/tmp/thumbnail_preview_%s.png", uuid.New().String())
+		root = ""
+	}
+	outPath, err := cleanOutputPath(outPath, root)
+	if err != nil {
+		return "", err
+	}
+
+	if err := extractFrame(videoURL, outPath, at); err != nil {
+		return "", err
+	}
+	return outPath, nil
+}
+
+// parseIDList "1,2,3" 형식의 ID 목록 파싱
+func parseIDList(s string) ([]int64, error) {
+	var ids []int64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ListSessions 삭제되지 않은 세션을 모듈 수와 함께 출력 (읽기 전용)
+// studentID가 0이면 전체 학생, titleLike가 비어있으면 전체 타이틀
+func (p *Parser) ListSessions(studentID int, titleLike string) error {
+	query := `
+		SELECT s.id, s.student_id, s.title, s.date,
+		       (SELECT COUNT(*) FROM learning_modules m WHERE m.session_id = s.id AND m.deleted_at IS NULL)
+		FROM learning_sessions s
+		WHERE s.deleted_at IS NULL
+		  AND ($1 = 0 OR s.student_id = $1)
+		  AND ($2 = '' OR s.title ILIKE '%' || $2 || '%')
+		ORDER BY s.student_id, s.date, s.id`
+
+	rows, err := p.db.Query(query, studentID, titleLike)
+	if err != nil {
+		return fmt.Errorf("세션 조회 실패 -> %w", err)
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	fmt.Println("=== 세션 목록 ===")
+	count := 0
+	for rows.Next() {
+		var id, moduleCount int64
+		var sessionStudentID int
+		var title string
+		var date time.Time
+		if err := rows.Scan(&id, &sessionStudentID, &title, &date, &moduleCount); err != nil {
+			return fmt.Errorf("세션 스캔 실패 -> %w", err)
+		}
+		fmt.Printf("  - ID %d: %s (student_id: %d, date: %s, 모듈 %d개)\n",
+			id, title, sessionStudentID, date.Format("2006-01-02"), moduleCount)
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("세션 조회 실패 -> %w", err)
+	}
+	fmt.Printf("총 %d개\n", count)
+
+	return nil
+}
+
+type normalizationFix struct {
+	ID            int64
+	Column        string
+	CurrentURL    string
+	NormalizedURL string
+}
+
+// FixNormalization NFD로 저장된 source_url/thumbnail_url 중 S3에 NFC 키만 존재하는 것을 NFC URL로 수정
+// s3-uploader는 NFC 키로 업로드하므로, 예전 NFD URL은 실제 객체와 일치하지 않을 수 있음
+func (p *Parser) FixNormalization(batchSize int) error {
+	if batchSize <= 0 {
+		return fmt.Errorf("batch-size는 1 이상이어야 합니다")
+	}
+
+	// 1. NFC가 아닌 URL 수집 (id 커서 기반 배치)
+	query := `
+		SELECT v.id, v.source_url, COALESCE(v.thumbnail_url, '')
+		FROM videos v
+		WHERE v.deleted_at IS NULL
+		  AND v.id > $1
+		ORDER BY v.id
+		LIMIT $2`
+
+	var candidates []normalizationFix
+	var lastID int64
+	for {
+		rows, err := p.db.Query(query, lastID, batchSize)
+		if err != nil {
+			return fmt.Errorf("비디오 조회 실패 -> %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var id int64
+			var sourceURL, thumbnailURL string
+			if err := rows.Scan(&id, &sourceURL, &thumbnailURL); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("비디오 스캔 실패 -> %w", err)
+			}
+			if nfcURL := norm.NFC.String(sourceURL); nfcURL != sourceURL {
+				candidates = append(candidates, normalizationFix{ID: id, Column: "source_url", CurrentURL: sourceURL, NormalizedURL: nfcURL})
+			}
+			if nfcURL := norm.NFC.String(thumbnailURL); nfcURL != thumbnailURL {
+				candidates = append(candidates, normalizationFix{ID: id, Column: "thumbnail_url", CurrentURL: thumbnailURL, NormalizedURL: nfcURL})
+			}
+			lastID = id
+			fetched++
+		}
+		_ = rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("비디오 조회 실패 -> %w", err)
+		}
+
+		if fetched < batchSize {
+			break
+		}
+	}
+
+	fmt.Println("=== NFC로 정규화되지 않은 URL ===")
+	fmt.Printf("총 %d개\n", len(candidates))
+
+	// 2. S3에 NFC 키만 존재하는 경우에만 수정
+	var fixed, skipped int
+	for _, c := range candidates {
+		currentKey, ok := p.urlToS3Key(c.CurrentURL)
+		if !ok {
+			loglevel.Infof("CloudFront URL이 아님, 스킵: ID %d %s (%s)", c.ID, c.Column, c.CurrentURL)
+			skipped++
+			continue
+		}
+		normalizedKey := norm.NFC.String(currentKey)
+
+		// 썸네일은 -thumbnail-bucket에 있음
+		bucket := p.bucketName
+		if c.Column == "thumbnail_url" {
+			bucket = p.thumbnailBucket
+		}
+		nfcExists, err := p.objectExistsIn(bucket, normalizedKey)
+		if err != nil {
+			return fmt.Errorf("S3 객체 확인 실패 (%s) -> %w", normalizedKey, err)
+		}
+		nfdExists, err := p.objectExistsIn(bucket, currentKey)
+		if err != nil {
+			return fmt.Errorf("S3 객체 확인 실패 (%s) -> %w", currentKey, err)
+		}
+		if !nfcExists || nfdExists {
+			loglevel.Infof("NFC 키만 존재하는 경우가 아님, 스킵: ID %d %s (NFC: %t, NFD: %t)", c.ID, c.Column, nfcExists, nfdExists)
+			skipped++
+			continue
+		}
+
+		// Column은 source_url/thumbnail_url 중 하나로 고정
+		updateQuery := fmt.Sprintf(`UPDATE videos SET %s = $1 WHERE id = $2 AND %s = $3`, c.Column, c.Column)
+		if _, err := p.db.Exec(updateQuery, c.NormalizedURL, c.ID, c.CurrentURL); err != nil {
+			return fmt.Errorf("비디오 URL 수정 실패 (ID %d) -> %w", c.ID, err)
+		}
+		fmt.Printf("  - ID %d %s: %s\n", c.ID, c.Column, c.NormalizedURL)
+		fixed++
+	}
+
+	log.Printf("✅ NFC 정규화 수정 완료: %d개 수정, %d개 스킵", fixed, skipped)
+	return nil
+}
+
+// urlToS3Key CloudFront URL(기존 주소, -stored-base-url, -thumbnail-base-url)을 S3 키로 변환
+func (p *Parser) urlToS3Key(url string) (string, bool) {
+	for _, base := range []string{cloudfrontBaseURL, p.storedBaseURL, p.thumbnailBaseURL} {
+		if encoded, ok := strings.CutPrefix(url, base+"/"); ok {
+			return urlPathDecode(encoded), true
+		}
+	}
+	return "", false
+}
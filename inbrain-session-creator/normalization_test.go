@@ -0,0 +1,133 @@
+package main
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestGetFilesInSectionNFCDuplicates(t *testing.T) {
+	const prefix = "lectures/세션/1_모듈/1_섹션/"
+	// 세션/모듈/섹션 폴더는 NFC로 찾으므로 파일 이름만 NFD로 만듦
+	nfc := func(name string) string { return prefix + norm.NFC.String(name) }
+	nfd := func(name string) string { return prefix + norm.NFD.String(name) }
+
+	tests := []struct {
+		name    string
+		objects []string
+		want    []string
+	}{
+		{"nfc only", []string{nfc("1_강의.mp4")}, []string{nfc("1_강의.mp4")}},
+		{"nfd only", []string{nfd("1_강의.mp4")}, []string{nfd("1_강의.mp4")}},
+		{"nfc and nfd prefer nfc", []string{nfd("1_강의.mp4"), nfc("1_강의.mp4")}, []string{nfc("1_강의.mp4")}},
+		{
+			name:    "mixed files",
+			objects: []string{nfd("1_강의.mp4"), nfc("1_강의.mp4"), nfd("2_해설.mp4")},
+			want:    []string{nfc("1_강의.mp4"), nfd("2_해설.mp4")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if nfc("1_강의.mp4") == nfd("1_강의.mp4") {
+				t.Fatal("NFD and NFC keys must differ")
+			}
+			stub, client := newS3Stub(t)
+			for _, key := range tt.objects {
+				stub.put("videos", key, []byte("x"))
+			}
+
+			p := newTestParser()
+			p.s3Client = client
+			p.bucketName = "videos"
+
+			got, err := p.GetFilesInSection("세션", "1_모듈", "1_섹션")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("files = %q, want %q", got, tt.want)
+			}
+			// 정렬 순서는 바이트 순이므로 NFC 기준으로 비교
+			gotSet := make(map[string]bool)
+			for _, key := range got {
+				gotSet[key] = true
+			}
+			for _, key := range tt.want {
+				if !gotSet[key] {
+					t.Errorf("files = %q, missing %q", got, key)
+				}
+			}
+		})
+	}
+}
+
+func TestFixNormalization(t *testing.T) {
+	const prefix = "lectures/세션/1_모듈/1_섹션/"
+	nfcKey := func(name string) string { return norm.NFC.String(prefix + name) }
+	nfdKey := func(name string) string { return norm.NFD.String(prefix + name) }
+	url := func(key string) string { return cloudfrontBaseURL + "/" + key }
+
+	for _, thumbnailBucket := range []string{"videos", "thumbnails"} {
+		t.Run("thumbnail bucket "+thumbnailBucket, func(t *testing.T) {
+			stub, client := newS3Stub(t)
+			stub.put("videos", nfcKey("1_강의.mp4"), []byte("x")) // NFC만 존재 -> 수정
+			stub.put("videos", nfcKey("2_강의.mp4"), []byte("x")) // 둘 다 존재 -> 스킵
+			stub.put("videos", nfdKey("2_강의.mp4"), []byte("x"))
+			stub.put("videos", nfdKey("3_강의.mp4"), []byte("x"))                  // NFD만 존재 -> 스킵
+			stub.put(thumbnailBucket, nfcKey("5_강의_thumbnail.png"), []byte("x")) // 썸네일도 NFC만 존재 -> 수정
+			stub.put("videos", nfcKey("5_강의.mp4"), []byte("x"))
+			stub.put("videos", nfcKey("4_강의.mp4"), []byte("x")) // 이미 NFC
+			stub.put(thumbnailBucket, nfcKey("4_강의_thumbnail.png"), []byte("x"))
+			if thumbnailBucket != "videos" {
+				// 영상 버킷에 남은 옛 NFD 썸네일은 확인 대상이 아님
+				stub.put("videos", nfdKey("5_강의_thumbnail.png"), []byte("x"))
+			}
+
+			videos := [][]driver.Value{
+				row(int64(1), url(nfdKey("1_강의.mp4")), ""),
+				row(int64(2), url(nfdKey("2_강의.mp4")), ""),
+				row(int64(3), url(nfdKey("3_강의.mp4")), ""),
+				row(int64(4), url(nfcKey("4_강의.mp4")), url(nfcKey("4_강의_thumbnail.png"))),
+				row(int64(5), url(nfcKey("5_강의.mp4")), url(nfdKey("5_강의_thumbnail.png"))),
+			}
+			db, fake := openFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				if strings.Contains(query, "FROM videos v") && args[0] == int64(0) {
+					return rows(videos...), nil
+				}
+				return nil, nil
+			})
+
+			p := newTestParser()
+			p.db = db
+			p.s3Client = client
+			p.bucketName = "videos"
+			p.thumbnailBucket = thumbnailBucket
+			p.storedBaseURL = cloudfrontBaseURL
+			p.thumbnailBaseURL = cloudfrontBaseURL
+
+			if err := p.FixNormalization(100); err != nil {
+				t.Fatal(err)
+			}
+
+			type update struct {
+				Column string
+				Args   []driver.Value
+			}
+			var got []update
+			for _, q := range fake.find("UPDATE videos SET") {
+				column := strings.Fields(q.SQL)[3]
+				got = append(got, update{Column: column, Args: q.Args})
+			}
+			want := []update{
+				{"source_url", []driver.Value{url(nfcKey("1_강의.mp4")), int64(1), url(nfdKey("1_강의.mp4"))}},
+				{"thumbnail_url", []driver.Value{url(nfcKey("5_강의_thumbnail.png")), int64(5), url(nfdKey("5_강의_thumbnail.png"))}},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("updates = %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckSectionNumbering(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{name: "consecutive", files: []string{"1_도입.mp4", "2_본론.mp4", "3_예제_해설_E1.mp4"}, want: nil},
+		{name: "all unnumbered", files: []string{"도입.mp4", "본론.mp4"}, want: nil},
+		{name: "starts above one", files: []string{"3_도입.mp4", "4_본론.mp4"}, want: nil},
+		{
+			name:  "mixed numbered and unnumbered",
+			files: []string{"1_도입.mp4", "부록.mp4", "2_본론.mp4", "정리.mp4"},
+			want:  []string{"번호 없는 파일이 섞여 있음: 부록.mp4, 정리.mp4"},
+		},
+		{
+			name:  "duplicate number",
+			files: []string{"1_도입.mp4", "2_본론.mp4", "2_본론_수정.mp4"},
+			want:  []string{"번호 2 중복: 2_본론.mp4, 2_본론_수정.mp4"},
+		},
+		{
+			name:  "duplicate across leading zeros",
+			files: []string{"01_도입.mp4", "1_도입.mp4"},
+			want:  []string{"번호 1 중복: 01_도입.mp4, 1_도입.mp4"},
+		},
+		{
+			name:  "single gap",
+			files: []string{"1_도입.mp4", "3_정리.mp4"},
+			want:  []string{"번호 2 누락 (1_도입.mp4 다음)"},
+		},
+		{
+			name:  "range gap",
+			files: []string{"1_도입.mp4", "5_정리.mp4"},
+			want:  []string{"번호 2~4 누락 (1_도입.mp4 다음)"},
+		},
+		{
+			name:  "mixed and duplicate",
+			files: []string{"1_도입.mp4", "1_도입2.mp4", "부록.mp4"},
+			want:  []string{"번호 없는 파일이 섞여 있음: 부록.mp4", "번호 1 중복: 1_도입.mp4, 1_도입2.mp4"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkSectionNumbering(tt.files); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checkSectionNumbering = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNumberingStrict(t *testing.T) {
+	keys := []string{
+		"세션/1_함수/0_극한/1_도입.mp4",
+		"세션/1_함수/0_극한/1_도입_수정.mp4",
+		"세션/1_함수/1_연속/1_도입.mp4",
+		"세션/1_함수/1_연속/부록.mp4",
+		"세션/2_미분/0_정의/1_도입.mp4",
+		"세션/2_미분/0_정의/2_정리.mp4",
+	}
+	tests := []struct {
+		name         string
+		strict       bool
+		allowUnnamed bool
+		wantErr      []string // 에러 메시지에 들어가야 하는 섹션
+	}{
+		{name: "warn only", strict: false},
+		// 이름 규칙에 맞지 않는 부록.mp4는 처리되지 않으므로 번호 검사에서도 빠짐
+		{name: "strict", strict: true, wantErr: []string{"1_함수/0_극한"}},
+		{name: "strict with unnamed files", strict: true, allowUnnamed: true, wantErr: []string{"1_함수/0_극한", "1_함수/1_연속"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newSessionHarness(t, nil, keys...)
+			h.p.strictNumbering = tt.strict
+			h.p.allowUnnamed = tt.allowUnnamed
+
+			err := h.p.ProcessSession("세션", "세션", 7, 1)
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("ProcessSession: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ProcessSession succeeded, want -strict-numbering error")
+			}
+			for _, section := range tt.wantErr {
+				if !strings.Contains(err.Error(), section) {
+					t.Errorf("error %q does not list %s", err, section)
+				}
+			}
+			if strings.Contains(err.Error(), "2_미분") {
+				t.Errorf("error %q lists a correctly numbered section", err)
+			}
+			// 번호 검사는 DB에 쓰기 전에 실행됨
+			if got := h.mem.counts()["sessions"]; got != 0 {
+				t.Errorf("%d sessions created before the numbering check failed", got)
+			}
+		})
+	}
+}
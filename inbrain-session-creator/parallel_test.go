@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// parallelKeys 모듈 2개 x 섹션 3개, 섹션마다 강의 3개와 해설 1개
+func parallelKeys() ([]string, []string) {
+	var keys, refIDs []string
+	for m := 1; m <= 2; m++ {
+		for s := 0; s < 3; s++ {
+			dir := fmt.Sprintf("세션/%d_모듈%d/%d_섹션%d", m, m, s, s)
+			for n := 1; n <= 3; n++ {
+				keys = append(keys, fmt.Sprintf("%s/%d_강의%d.mp4", dir, n, n))
+			}
+			refID := fmt.Sprintf("E%d%d", m, s)
+			refIDs = append(refIDs, refID)
+			keys = append(keys, fmt.Sprintf("%s/4_해설_%s.mp4", dir, refID))
+		}
+	}
+	return keys, refIDs
+}
+
+// runLayout 섹션별 "title|sequence"와 콘텐츠 "type:sequence" 목록 (ID와 무관한 결과 비교용)
+func runLayout(h *sessionHarness) ([]string, []string) {
+	var contents []string
+	for _, c := range h.mem.contentList() {
+		contents = append(contents, fmt.Sprintf("%s:%d", c.Type, c.Sequence))
+	}
+	sort.Strings(contents)
+	return h.mem.sectionTitles(), contents
+}
+
+// go test -race로 실행해야 공유 상태(실패 목록, ID 맵, 캐시) 경쟁을 잡을 수 있음
+func TestParallelSectionsMatchSequentialRun(t *testing.T) {
+	keys, refIDs := parallelKeys()
+
+	sequential := newSessionHarness(t, refIDs, keys...)
+	sequential.run("세션", "세션")
+	wantSections, wantContents := runLayout(sequential)
+
+	parallel := newSessionHarness(t, refIDs, keys...)
+	parallel.p.parallelSections = 4
+	parallel.run("세션", "세션")
+	gotSections, gotContents := runLayout(parallel)
+
+	if !reflect.DeepEqual(gotSections, wantSections) {
+		t.Errorf("sections = %v, want %v", gotSections, wantSections)
+	}
+	if !reflect.DeepEqual(gotContents, wantContents) {
+		t.Errorf("contents = %v, want %v", gotContents, wantContents)
+	}
+	if len(parallel.p.idMapRows) != len(keys) {
+		t.Errorf("id map has %d rows, want %d (one per file)", len(parallel.p.idMapRows), len(keys))
+	}
+	if len(parallel.p.failedFiles) != 0 || len(parallel.p.failedKeys) != 0 {
+		t.Errorf("failed files = %v, %v; want none", parallel.p.failedFiles, parallel.p.failedKeys)
+	}
+}
+
+func TestSectionRunner(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+	}{
+		{name: "sequential", limit: 1},
+		{name: "parallel", limit: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := newSectionRunner(tt.limit)
+			var mu sync.Mutex
+			running, maxRunning, done := 0, 0, 0
+			for i := 0; i < 10; i++ {
+				_ = runner.Go(func() error {
+					mu.Lock()
+					running++
+					maxRunning = max(maxRunning, running)
+					mu.Unlock()
+					time.Sleep(time.Millisecond)
+					mu.Lock()
+					running--
+					done++
+					mu.Unlock()
+					return nil
+				})
+			}
+			if err := runner.Wait(); err != nil {
+				t.Fatalf("Wait: %v", err)
+			}
+			if done != 10 {
+				t.Errorf("%d tasks finished, want 10", done)
+			}
+			if maxRunning > tt.limit {
+				t.Errorf("%d tasks ran at once, limit %d", maxRunning, tt.limit)
+			}
+		})
+	}
+}
+
+func TestSectionRunnerReturnsFirstError(t *testing.T) {
+	runner := newSectionRunner(2)
+	errFirst := errors.New("first")
+	_ = runner.Go(func() error { return errFirst })
+	_ = runner.Go(func() error {
+		time.Sleep(10 * time.Millisecond)
+		return errors.New("second")
+	})
+	if err := runner.Wait(); !errors.Is(err, errFirst) {
+		t.Errorf("Wait = %v, want %v", err, errFirst)
+	}
+	// 에러 이후의 Go는 그 에러를 돌려줘 호출자가 멈출 수 있어야 함
+	if err := runner.Go(func() error { return nil }); !errors.Is(err, errFirst) {
+		t.Errorf("Go after error = %v, want %v", err, errFirst)
+	}
+	_ = runner.Wait()
+}
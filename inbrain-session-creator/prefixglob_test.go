@@ -0,0 +1,50 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestMatchPrefixesAgainstListing(t *testing.T) {
+	day3NFD := norm.NFD.String("공통수학2 Day3")
+	stub, client := newS3Stub(t)
+	for _, name := range []string{"공통수학2 Day1", "공통수학2 Day2", "공통수학2 Day10", day3NFD, "공통수학1 Day1", "공통수학2 Review", ".공통수학2 Day4", " "} {
+		stub.put("videos", "lectures/"+name+"/1_모듈/0_섹션/1_강의.mp4", []byte("x"))
+	}
+	stub.put("videos", "lectures/공통수학2 Day5.mp4", []byte("x"))
+
+	p := newTestParser()
+	p.s3Client = client
+	p.bucketName = "videos"
+	names, err := p.listSessionPrefixes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+		wantErr bool
+	}{
+		{name: "all days", pattern: "공통수학2 Day*", want: []string{"공통수학2 Day1", "공통수학2 Day10", "공통수학2 Day2", day3NFD}},
+		{name: "single character", pattern: "공통수학2 Day?", want: []string{"공통수학2 Day1", "공통수학2 Day2", day3NFD}},
+		{name: "NFD pattern", pattern: norm.NFD.String("공통수학2 Day1*"), want: []string{"공통수학2 Day1", "공통수학2 Day10"}},
+		{name: "exact name", pattern: "공통수학1 Day1", want: []string{"공통수학1 Day1"}},
+		{name: "no match", pattern: "공통수학3 *", want: nil},
+		{name: "bad pattern", pattern: "공통수학2 [", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchPrefixes(names, tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchPrefixes(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchPrefixes(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
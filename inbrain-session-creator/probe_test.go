@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+// 컨테이너 길이가 짧게 기록된 .mov (format 12.9초, 비디오 스트림 95.4초)
+const shortContainerProbe = `{
+  "streams": [
+    {"codec_type": "audio", "duration": "200.0"},
+    {"codec_type": "video", "duration": "95.400000"}
+  ],
+  "format": {"duration": "12.900000"}
+}`
+
+func TestParseProbeDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		source  string
+		want    int
+		wantErr bool
+	}{
+		{name: "format", output: shortContainerProbe, source: "format", want: 12},
+		{name: "stream skips audio", output: shortContainerProbe, source: "stream", want: 95},
+		{name: "max", output: shortContainerProbe, source: "max", want: 95},
+		{name: "max keeps longer format", output: `{"streams": [{"codec_type": "video", "duration": "40.0"}], "format": {"duration": "41.5"}}`, source: "max", want: 41},
+		{name: "stream falls back to format", output: `{"streams": [{"codec_type": "audio", "duration": "30.0"}], "format": {"duration": "20.0"}}`, source: "stream", want: 20},
+		{name: "format falls back to stream", output: `{"streams": [{"codec_type": "video", "duration": "33.3"}], "format": {}}`, source: "format", want: 33},
+		{name: "no duration", output: `{"streams": [], "format": {"duration": "N/A"}}`, source: "max", wantErr: true},
+		{name: "not JSON", output: `30.0`, source: "format", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProbeDuration([]byte(tt.output), tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProbeDuration error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseProbeDuration = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeDurationUsesProbeSource(t *testing.T) {
+	fakeTool(t, "ffprobe", "cat <<'JSON'\n"+shortContainerProbe+"\nJSON")
+
+	tests := []struct {
+		source string
+		want   int
+	}{
+		{source: "stream", want: 95},
+		{source: "max", want: 95},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			p := newTestParser()
+			p.probeSource = tt.source
+			got, err := p.probeDuration("https://example.com/a.mov", "lectures/a.mov")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("probeDuration = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/unboxerscorp/utility/common/loglevel"
+)
+
+// progressTracker -metrics-addr로 노출할 현재 진행 상황
+// 섹션을 동시에 처리하면 Module/Section/File은 가장 최근에 시작한 것을 가리킴
+type progressTracker struct {
+	mu sync.Mutex
+
+	StartedAt      time.Time
+	Session        string
+	Module         string
+	Section        string
+	File           string
+	SessionsDone   int
+	ModulesStarted int
+	SectionsDone   int
+	FilesStarted   int
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{StartedAt: time.Now()}
+}
+
+func (t *progressTracker) update(fn func(t *progressTracker)) {
+	t.mu.Lock()
+	fn(t)
+	t.mu.Unlock()
+}
+
+// progressSnapshot /progress 응답 (실패 수는 Parser의 실패 목록에서 채움)
+type progressSnapshot struct {
+	StartedAt      time.Time `json:"started_at"`
+	ElapsedSeconds int64     `json:"elapsed_seconds"`
+	Session        string    `json:"session"`
+	Module         string    `json:"module"`
+	Section        string    `json:"section"`
+	File           string    `json:"file"`
+	SessionsDone   int       `json:"sessions_done"`
+	ModulesStarted int       `json:"modules_started"`
+	SectionsDone   int       `json:"sections_done"`
+	FilesStarted   int       `json:"files_started"`
+	FailedFiles    int       `json:"failed_files"`
+	Quarantined    int       `json:"quarantined_files"`
+}
+
+func (p *Parser) progressSnapshot() progressSnapshot {
+	t := p.progress
+	t.mu.Lock()
+	s := progressSnapshot{
+		StartedAt:      t.StartedAt,
+		ElapsedSeconds: int64(time.Since(t.StartedAt).Seconds()),
+		Session:        t.Session,
+		Module:         t.Module,
+		Section:        t.Section,
+		File:           t.File,
+		SessionsDone:   t.SessionsDone,
+		ModulesStarted: t.ModulesStarted,
+		SectionsDone:   t.SectionsDone,
+		FilesStarted:   t.FilesStarted,
+	}
+	t.mu.Unlock()
+
+	p.failedMu.Lock()
+	s.FailedFiles = len(p.failedKeys)
+	for _, f := range p.failedFiles {
+		if !f.Fallback {
+			s.Quarantined++
+		}
+	}
+	p.failedMu.Unlock()
+	return s
+}
+
+// StartMetrics addr에서 /healthz와 /progress(JSON)를 제공하는 HTTP 서버를 시작. Close에서 종료됨
+func (p *Parser) StartMetrics(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/progress", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(p.progressSnapshot())
+	})
+
+	p.metricsServer = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := p.metricsServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			loglevel.Warnf("진행 상황 서버 종료: %v", err)
+		}
+	}()
+	loglevel.Infof("진행 상황 서버 시작: http://%s/progress", listener.Addr())
+	return nil
+}
+
+// stopMetrics 진행 중인 요청을 잠시 기다린 뒤 서버를 닫음
+func (p *Parser) stopMetrics() {
+	if p.metricsServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = p.metricsServer.Shutdown(ctx)
+	p.metricsServer = nil
+}
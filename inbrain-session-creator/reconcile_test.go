@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReconcileReplacesChangedFileWithSameCount(t *testing.T) {
+	h := newSessionHarness(t, nil,
+		"세션/1_함수/0_극한/1_도입.mp4",
+		"세션/1_함수/0_극한/2_정리.mp4",
+	)
+	h.run("세션", "세션")
+	before := h.mem.counts()
+
+	// 파일 수는 그대로 두고 2번 영상만 다른 파일로 바꿈
+	h.stub.mu.Lock()
+	delete(h.stub.objects, "videos/lectures/세션/1_함수/0_극한/2_정리.mp4")
+	h.stub.mu.Unlock()
+	h.stub.put("videos", "lectures/세션/1_함수/0_극한/2_정리_수정.mp4", []byte("video"))
+
+	// -reconcile 없이는 개수가 같아 섹션을 건너뜀
+	h.run("세션", "세션")
+	if got := h.mem.counts(); got["videos"] != before["videos"] {
+		t.Fatalf("run without -reconcile created %d videos, want none", got["videos"]-before["videos"])
+	}
+
+	h.p.reconcile = true
+	h.run("세션", "세션")
+
+	after := h.mem.counts()
+	if after["videos"] != before["videos"]+1 {
+		t.Errorf("reconcile created %d videos, want 1 (only the changed file)", after["videos"]-before["videos"])
+	}
+	for _, table := range []string{"contents", "lectures", "sections"} {
+		if after[table] != before[table] {
+			t.Errorf("%s: %d rows after reconcile, want %d", table, after[table], before[table])
+		}
+	}
+
+	sources := make(map[int64]string)
+	for _, c := range h.mem.contentList() {
+		h.mem.mu.Lock()
+		sources[c.Sequence] = h.mem.videos[h.mem.lectures[c.LectureID]]
+		h.mem.mu.Unlock()
+	}
+	if !strings.Contains(sources[1], "1_도입.mp4") {
+		t.Errorf("sequence 1 video = %q, want the unchanged 1_도입.mp4", sources[1])
+	}
+	if !strings.Contains(sources[2], "2_정리_수정.mp4") {
+		t.Errorf("sequence 2 video = %q, want the replacement 2_정리_수정.mp4", sources[2])
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// redirectServer /old/ 아래 경로를 /edge/로 301 리다이렉트하고 HEAD 요청 수를 셈
+func redirectServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var heads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+		}
+		if rest, ok := strings.CutPrefix(r.URL.Path, "/old/"); ok {
+			http.Redirect(w, r, "/edge/"+rest, http.StatusMovedPermanently)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, &heads
+}
+
+func TestResolveVideoURL(t *testing.T) {
+	server, heads := redirectServer(t)
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		probeViaS3 bool
+		url        string
+		want       string
+		wantHeads  int32
+	}{
+		{"follows redirect", false, server.URL + "/old/1.mp4", server.URL + "/edge/1.mp4", 2},
+		{"no redirect", false, server.URL + "/edge/2.mp4", server.URL + "/edge/2.mp4", 1},
+		{"presigned url is not checked", true, server.URL + "/old/3.mp4?X-Amz-Signature=abc", server.URL + "/old/3.mp4?X-Amz-Signature=abc", 0},
+		{"head failure keeps url", false, closed.URL + "/4.mp4", closed.URL + "/4.mp4", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			heads.Store(0)
+			p := newTestParser()
+			p.probeViaS3 = tt.probeViaS3
+
+			if got := p.resolveVideoURL(tt.url); got != tt.want {
+				t.Errorf("resolveVideoURL = %s, want %s", got, tt.want)
+			}
+			if got := heads.Load(); got != tt.wantHeads {
+				t.Errorf("HEAD requests = %d, want %d", got, tt.wantHeads)
+			}
+		})
+	}
+}
+
+// countingTransport 보낸 요청 수만 세고 실패시킴
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (c *countingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	c.requests.Add(1)
+	return nil, http.ErrHandlerTimeout
+}
+
+func TestReadURLSendsNoRequests(t *testing.T) {
+	transport := &countingTransport{}
+	saved := httpClient
+	httpClient = &http.Client{Transport: transport}
+	t.Cleanup(func() { httpClient = saved })
+
+	_, client := newS3Stub(t)
+	for _, probeViaS3 := range []bool{false, true} {
+		p := newTestParser()
+		p.bucketName = "videos"
+		p.probeViaS3 = probeViaS3
+		p.presignClient = s3.NewPresignClient(client)
+
+		if _, err := p.readURL("lectures/s/m/1_강의.mp4"); err != nil {
+			t.Fatalf("probeViaS3=%v: %v", probeViaS3, err)
+		}
+	}
+	if n := transport.requests.Load(); n != 0 {
+		t.Errorf("readURL sent %d requests, want 0 (redirects are resolved only before reading)", n)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3Stub 경로 방식(path-style) 요청만 처리하는 메모리 S3 (ListObjectsV2, HeadObject, GetObject, PutObject)
+type s3Stub struct {
+	mu       sync.Mutex
+	objects  map[string]s3StubObject // "버킷/키"
+	requests []string                // "METHOD 버킷/키"
+}
+
+type s3StubObject struct {
+	Body        []byte
+	ContentType string
+}
+
+// newS3Stub 스텁 서버와 그 서버를 가리키는 S3 클라이언트
+func newS3Stub(t testing.TB) (*s3Stub, *s3.Client) {
+	t.Helper()
+	stub := &s3Stub{objects: make(map[string]s3StubObject)}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	client := s3.New(s3.Options{
+		BaseEndpoint:               aws.String(server.URL),
+		UsePathStyle:               true,
+		Region:                     "us-east-1",
+		Credentials:                aws.CredentialsProviderFunc(stubCredentials),
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+		RetryMaxAttempts:           1,
+	})
+	return stub, client
+}
+
+// stubCredentials presigned URL도 만들 수 있도록 고정된 가짜 자격 증명
+func stubCredentials(context.Context) (aws.Credentials, error) {
+	return aws.Credentials{AccessKeyID: "AKIDTEST", SecretAccessKey: "secret", Source: "s3stub"}, nil
+}
+
+// put 객체를 미리 만들어 둠
+func (s *s3Stub) put(bucket, key string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[bucket+"/"+key] = s3StubObject{Body: body}
+}
+
+func (s *s3Stub) object(bucket, key string) (s3StubObject, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[bucket+"/"+key]
+	return obj, ok
+}
+
+// count method 요청 수 (예: "PUT", "HEAD")
+func (s *s3Stub) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if strings.HasPrefix(r, method+" ") {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+bucket+"/"+key)
+	s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && key == "" && r.URL.Query().Get("list-type") == "2":
+		s.list(w, bucket, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"))
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		obj, ok := s.object(bucket, key)
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			}
+			return
+		}
+		w.Header().Set("ETag", fmt.Sprintf("\"%x\"", md5.Sum(obj.Body))) //nolint:gosec
+		w.Header().Set("Content-Length", fmt.Sprint(len(obj.Body)))
+		if obj.ContentType != "" {
+			w.Header().Set("Content-Type", obj.ContentType)
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.Body)
+		}
+	case r.Method == http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.objects[bucket+"/"+key] = s3StubObject{Body: body, ContentType: r.Header.Get("Content-Type")}
+		s.mu.Unlock()
+		w.Header().Set("ETag", fmt.Sprintf("\"%x\"", md5.Sum(body))) //nolint:gosec
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+type s3StubListResult struct {
+	XMLName        xml.Name `xml:"ListBucketResult"`
+	Name           string
+	Prefix         string
+	KeyCount       int
+	IsTruncated    bool
+	Contents       []s3StubListObject
+	CommonPrefixes []s3StubPrefix
+}
+
+type s3StubListObject struct {
+	Key  string
+	Size int
+}
+
+type s3StubPrefix struct {
+	Prefix string
+}
+
+func (s *s3Stub) list(w http.ResponseWriter, bucket, prefix, delimiter string) {
+	s.mu.Lock()
+	var keys []string
+	sizes := make(map[string]int)
+	for name, obj := range s.objects {
+		b, key, _ := strings.Cut(name, "/")
+		if b == bucket && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+			sizes[key] = len(obj.Body)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(keys)
+
+	result := s3StubListResult{Name: bucket, Prefix: prefix}
+	seenPrefixes := make(map[string]bool)
+	for _, key := range keys {
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				common := key[:len(prefix)+i+len(delimiter)]
+				if !seenPrefixes[common] {
+					seenPrefixes[common] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, s3StubPrefix{Prefix: common})
+				}
+				continue
+			}
+		}
+		result.Contents = append(result.Contents, s3StubListObject{Key: key, Size: sizes[key]})
+	}
+	result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
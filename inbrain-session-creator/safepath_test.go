@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanOutputPath(t *testing.T) {
+	root := t.TempDir()
+	tests := []struct {
+		name     string
+		filename string
+		root     string
+		wantErr  bool
+	}{
+		{"plain", "failed.txt", "", false},
+		{"double dot in name", "a..b.mov", "", false},
+		{"double dot in dir name", "세션/v1..v2/1_강의.mp4", "", false},
+		{"inside root", filepath.Join(root, "failed.txt"), root, false},
+		{"parent", "../x", "", true},
+		{"parent in middle", "d/../x", "", true},
+		{"outside root", filepath.Join(filepath.Dir(root), "failed.txt"), root, true},
+		{"sibling with root prefix", root + "-other/failed.txt", root, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cleanOutputPath(tt.filename, tt.root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cleanOutputPath(%q, %q) = %q, %v; wantErr %v", tt.filename, tt.root, got, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSaveProbeOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		s3Path  string
+		inRoot  bool
+		wantErr bool
+	}{
+		{"double dot in name", "세션/모듈/a..b.mov", true, false},
+		{"parent segment", "세션/../../x.mov", true, true},
+		{"outside output-root", "세션/모듈/1_강의.mp4", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, "probe")
+			if !tt.inRoot {
+				dir = t.TempDir()
+			}
+
+			err := saveProbeOutput(dir, root, tt.s3Path, []byte("{}"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("saveProbeOutput(%q) = %v, wantErr %v", tt.s3Path, err, tt.wantErr)
+			}
+			if err == nil {
+				if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(tt.s3Path)+".probe.json")); err != nil {
+					t.Errorf("probe output not written: %v", err)
+				}
+			}
+		})
+	}
+}
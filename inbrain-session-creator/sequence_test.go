@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFileSequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		sortMode string
+		files    []string
+		want     []int
+	}{
+		{
+			name:     "all unnumbered with key sort",
+			sortMode: "key",
+			files:    []string{"가.mov", "나.mov", "다.mov"},
+			want:     []int{1, 2, 3},
+		},
+		{
+			name:     "numbered and unnumbered with key sort do not collide",
+			sortMode: "key",
+			files:    []string{"1_도입.mov", "2_본론.mov", "부록.mov"},
+			want:     []int{1, 2, 3},
+		},
+		{
+			name:     "key sort ignores out-of-order numbers",
+			sortMode: "key",
+			files:    []string{"10_끝.mov", "2_처음.mov"},
+			want:     []int{1, 2},
+		},
+		{
+			name:     "sequence sort uses filename numbers",
+			sortMode: "sequence",
+			files:    []string{"1_도입.mov", "3_본론.mov", "부록.mov"},
+			want:     []int{1, 3, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]int, len(tt.files))
+			seen := make(map[int]bool)
+			for i, file := range tt.files {
+				got[i] = fileSequence(file, i, tt.sortMode)
+				if tt.sortMode == "key" && seen[got[i]] {
+					t.Errorf("duplicate sequence %d for %s", got[i], file)
+				}
+				seen[got[i]] = true
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sequences = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
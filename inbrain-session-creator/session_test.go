@@ -0,0 +1,431 @@
+package main
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// memSessionDB 세션 생성 흐름에서 쓰는 테이블을 메모리로 흉내 내는 fakeDB handler
+// 세션/모듈/섹션/콘텐츠/비디오/강의는 실제로 저장되므로 같은 DB로 두 번 실행하면 재사용/스킵 동작을 확인할 수 있음
+type memSessionDB struct {
+	mu        sync.Mutex
+	nextID    int64
+	sessions  map[string][]int64 // "student_id|title" (-on-existing=new이면 같은 키에 여러 행)
+	modules   map[string][]int64 // "session_id|title|sequence"
+	sections  map[string][]int64 // "module_id|title|sequence"
+	videos    map[int64]string   // id -> source_url
+	lectures  map[int64]int64    // id -> lecture_video_id
+	exercises map[string]*memExercise
+	contents  []memContent
+}
+
+type memExercise struct {
+	ID              int64
+	SolutionVideoID int64
+}
+
+type memContent struct {
+	ID         int64
+	Title      string
+	Type       string
+	LectureID  int64
+	ExerciseID int64
+	Sequence   int64
+	SectionID  int64
+	UserID     int64
+}
+
+// newMemSessionDB refIDs의 연습문제가 있는 빈 DB
+func newMemSessionDB(refIDs ...string) *memSessionDB {
+	m := &memSessionDB{
+		nextID:    100,
+		sessions:  make(map[string][]int64),
+		modules:   make(map[string][]int64),
+		sections:  make(map[string][]int64),
+		videos:    make(map[int64]string),
+		lectures:  make(map[int64]int64),
+		exercises: make(map[string]*memExercise),
+	}
+	for _, refID := range refIDs {
+		m.exercises[refID] = &memExercise{ID: m.newID()}
+	}
+	return m
+}
+
+func (m *memSessionDB) newID() int64 {
+	m.nextID++
+	return m.nextID
+}
+
+func memKey(values ...driver.Value) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, "|")
+}
+
+// lookup 테이블에서 key의 첫 ID를 찾아 한 행으로 반환 (없으면 빈 결과)
+func lookup(table map[string][]int64, key string) *fakeResult {
+	if ids := table[key]; len(ids) > 0 {
+		return rows(row(ids[0]))
+	}
+	return &fakeResult{}
+}
+
+// insert 새 ID를 만들어 table에 추가하고 RETURNING id 결과로 반환
+func (m *memSessionDB) insert(table map[string][]int64, key string) *fakeResult {
+	id := m.newID()
+	table[key] = append(table[key], id)
+	return rows(row(id))
+}
+
+func rowCount(table map[string][]int64) int {
+	n := 0
+	for _, ids := range table {
+		n += len(ids)
+	}
+	return n
+}
+
+func (m *memSessionDB) handle(query string, args []driver.Value) (*fakeResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case strings.Contains(query, "FROM learning_sessions WHERE"):
+		return lookup(m.sessions, memKey(args[0], args[1])), nil
+	case strings.Contains(query, "INSERT INTO learning_sessions"):
+		return m.insert(m.sessions, memKey(args[0], args[2])), nil
+	case strings.Contains(query, "FROM learning_modules WHERE"):
+		return lookup(m.modules, memKey(args...)), nil
+	case strings.Contains(query, "INSERT INTO learning_modules"):
+		return m.insert(m.modules, memKey(args[3], args[0], args[2])), nil
+	case strings.Contains(query, "FROM learning_sections WHERE"):
+		return lookup(m.sections, memKey(args...)), nil
+	case strings.Contains(query, "INSERT INTO learning_sections"):
+		return m.insert(m.sections, memKey(args[2], args[0], args[1])), nil
+
+	case strings.Contains(query, "SELECT COUNT(*) FROM learning_contents"):
+		return rows(row(int64(len(m.sectionContents(args[0], args[1]))))), nil
+	case strings.Contains(query, "SELECT id FROM learning_contents"):
+		for _, c := range m.sectionContents(args[0], args[2]) {
+			if c.Type == "exercise" && c.Sequence == args[1] {
+				return rows(row(c.ID)), nil
+			}
+		}
+		return &fakeResult{}, nil
+	case strings.Contains(query, "SELECT id, lecture_id FROM learning_contents"):
+		for _, c := range m.sectionContents(args[0], args[2]) {
+			if c.Type == "lecture" && c.Sequence == args[1] {
+				return rows(row(c.ID, c.LectureID)), nil
+			}
+		}
+		return &fakeResult{}, nil
+	case strings.Contains(query, "SELECT id, content_type, sequence FROM learning_contents"):
+		result := &fakeResult{}
+		for _, c := range m.sectionContents(args[0], args[1]) {
+			result.Rows = append(result.Rows, row(c.ID, c.Type, c.Sequence))
+		}
+		return result, nil
+	case strings.Contains(query, "FROM learning_contents lc"):
+		result := &fakeResult{}
+		for _, c := range m.sectionContents(args[0], args[1]) {
+			videoID := m.lectures[c.LectureID]
+			for _, ex := range m.exercises {
+				if c.Type == "exercise" && ex.ID == c.ExerciseID {
+					videoID = ex.SolutionVideoID
+				}
+			}
+			result.Rows = append(result.Rows, row(c.Type, c.Sequence, m.videos[videoID]))
+		}
+		return result, nil
+	case strings.Contains(query, "INSERT INTO learning_contents"):
+		return m.insertContents(query, args), nil
+
+	case strings.Contains(query, "INSERT INTO videos"):
+		id := m.newID()
+		m.videos[id] = args[2].(string)
+		return rows(row(id)), nil
+	case strings.Contains(query, "SELECT id FROM lectures WHERE lecture_video_id"):
+		for id, videoID := range m.lectures {
+			if videoID == args[0] {
+				return rows(row(id)), nil
+			}
+		}
+		return &fakeResult{}, nil
+	case strings.Contains(query, "INSERT INTO lectures"):
+		id := m.newID()
+		m.lectures[id] = args[2].(int64)
+		return rows(row(id)), nil
+	case strings.Contains(query, "UPDATE lectures SET lecture_video_id"):
+		m.lectures[args[1].(int64)] = args[0].(int64)
+		return nil, nil
+
+	case strings.Contains(query, "SELECT solution_video_id FROM exercises"):
+		ex, ok := m.exercises[args[0].(string)]
+		if !ok {
+			return &fakeResult{}, nil
+		}
+		if ex.SolutionVideoID == 0 {
+			return rows(row(nil)), nil
+		}
+		return rows(row(ex.SolutionVideoID)), nil
+	case strings.Contains(query, "UPDATE exercises SET solution_video_id"):
+		if ex, ok := m.exercises[args[1].(string)]; ok {
+			ex.SolutionVideoID = args[0].(int64)
+			return nil, nil
+		}
+		return &fakeResult{}, nil
+	case strings.Contains(query, "SELECT id FROM exercises WHERE ref_id = $1"):
+		if ex, ok := m.exercises[args[0].(string)]; ok {
+			return rows(row(ex.ID)), nil
+		}
+		return &fakeResult{}, nil
+	case strings.Contains(query, "SELECT ref_id, id FROM exercises WHERE ref_id = ANY"):
+		result := &fakeResult{}
+		refIDs := strings.Split(strings.Trim(args[0].(string), "{}"), ",")
+		for _, refID := range refIDs {
+			refID = strings.Trim(refID, `"`)
+			if ex, ok := m.exercises[refID]; ok {
+				result.Rows = append(result.Rows, row(refID, ex.ID))
+			}
+		}
+		return result, nil
+	}
+	return nil, nil
+}
+
+func (m *memSessionDB) sectionContents(sectionID, userID driver.Value) []memContent {
+	var found []memContent
+	for _, c := range m.contents {
+		if c.SectionID == sectionID && c.UserID == userID {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// insertContents 한 행 또는 여러 행 INSERT INTO learning_contents (-batch-insert-contents)
+func (m *memSessionDB) insertContents(query string, args []driver.Value) *fakeResult {
+	perRow := 5 // title, lecture_id, sequence, section_id, user_id
+	if strings.Contains(query, "exercise_type") {
+		perRow = 6 // title, exercise_id, exercise_type, sequence, section_id, user_id
+	}
+
+	result := &fakeResult{}
+	for start := 0; start+perRow <= len(args); start += perRow {
+		a := args[start : start+perRow]
+		c := memContent{ID: m.newID(), Title: a[0].(string)}
+		if perRow == 5 {
+			c.Type, c.LectureID = "lecture", a[1].(int64)
+		} else {
+			c.Type, c.ExerciseID = "exercise", a[1].(int64)
+		}
+		c.Sequence, c.SectionID, c.UserID = a[perRow-3].(int64), a[perRow-2].(int64), a[perRow-1].(int64)
+		m.contents = append(m.contents, c)
+		if strings.Contains(query, "RETURNING id, sequence") {
+			result.Rows = append(result.Rows, row(c.ID, c.Sequence))
+		} else {
+			result.Rows = append(result.Rows, row(c.ID))
+		}
+	}
+	return result
+}
+
+// counts 테이블별 행 수
+func (m *memSessionDB) counts() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return map[string]int{
+		"sessions": rowCount(m.sessions),
+		"modules":  rowCount(m.modules),
+		"sections": rowCount(m.sections),
+		"contents": len(m.contents),
+		"videos":   len(m.videos),
+		"lectures": len(m.lectures),
+	}
+}
+
+// sectionTitles 만들어진 섹션의 "title|sequence" 목록 (정렬)
+func (m *memSessionDB) sectionTitles() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var titles []string
+	for key, ids := range m.sections {
+		_, rest, _ := strings.Cut(key, "|")
+		for range ids {
+			titles = append(titles, rest)
+		}
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+// contentList 섹션별 sequence 순 콘텐츠 (정렬)
+func (m *memSessionDB) contentList() []memContent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := append([]memContent(nil), m.contents...)
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].SectionID != list[j].SectionID {
+			return list[i].SectionID < list[j].SectionID
+		}
+		if list[i].Sequence != list[j].Sequence {
+			return list[i].Sequence < list[j].Sequence
+		}
+		return list[i].Type < list[j].Type
+	})
+	return list
+}
+
+// offlineTransport 네트워크 없이 HEAD(리다이렉트 확인)에는 리다이렉트 없는 200으로 답하고 나머지 요청(MD5 다운로드)은 실패시킴
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodHead {
+		return nil, http.ErrHandlerTimeout
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+// fakeFFmpeg 썸네일 출력 파일만 만드는 ffmpeg (출력 경로는 마지막 인자(-y) 바로 앞)
+const fakeFFmpeg = `for a in "$@"; do out=$prev; prev=$a; done
+echo png > "$out"`
+
+// sessionHarness S3 스텁, 메모리 DB, 가짜 ffprobe/ffmpeg로 ProcessSession 전체를 실행하는 테스트 환경
+type sessionHarness struct {
+	t    testing.TB
+	stub *s3Stub
+	mem  *memSessionDB
+	fake *fakeDB
+	p    *Parser
+}
+
+// newSessionHarness keys(lectures/ 아래 S3 키)에 영상이 있고 refIDs의 연습문제가 있는 환경
+// Parser는 테스트 모드(MD5 확인 없음)이고 -on-existing=reuse
+func newSessionHarness(t testing.TB, refIDs []string, keys ...string) *sessionHarness {
+	t.Helper()
+	fakeTool(t, "ffprobe", "echo 30.0")
+	fakeTool(t, "ffmpeg", fakeFFmpeg)
+
+	saved := httpClient
+	httpClient = &http.Client{Transport: offlineTransport{}}
+	t.Cleanup(func() { httpClient = saved })
+
+	stub, client := newS3Stub(t)
+	for _, key := range keys {
+		stub.put("videos", "lectures/"+key, []byte("video"))
+	}
+
+	mem := newMemSessionDB(refIDs...)
+	db, fake := openFakeDB(t, mem.handle)
+
+	p := newTestParser()
+	p.db = db
+	p.s3Client = client
+	p.presignClient = s3.NewPresignClient(client)
+	p.bucketName = "videos"
+	p.thumbnailBucket = "videos"
+	p.storedBaseURL = cloudfrontBaseURL
+	p.thumbnailBaseURL = cloudfrontBaseURL
+	p.onExisting = "reuse"
+	p.sortMode = "sequence"
+	p.titleTemplate = "{filename}"
+	p.defaultSectionName = "기본"
+	p.parallelSections = 1
+	return &sessionHarness{t: t, stub: stub, mem: mem, fake: fake, p: p}
+}
+
+// recordToolArgs 실행 인자를 한 줄씩 기록하는 가짜 도구를 PATH에 두고, 지금까지 기록된 인자 목록을 돌려주는 함수를 반환
+func recordToolArgs(t testing.TB, name, body string) func() []string {
+	t.Helper()
+	argsFile := filepath.Join(t.TempDir(), name+".args")
+	fakeTool(t, name, `echo "$*" >> '`+argsFile+`'
+`+body)
+	return func() []string {
+		data, err := os.ReadFile(argsFile)
+		if err != nil {
+			return nil
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+}
+
+// run 세션을 처리하고 실패하면 테스트를 중단
+func (h *sessionHarness) run(sessionName, s3Prefix string) {
+	h.t.Helper()
+	if err := h.p.ProcessSession(sessionName, s3Prefix, 7, 1); err != nil {
+		h.t.Fatalf("ProcessSession(%s): %v", s3Prefix, err)
+	}
+}
+
+func TestLooseModuleFilesUseDefaultSection(t *testing.T) {
+	h := newSessionHarness(t, nil,
+		"세션/1_모듈/1_첫강의.mp4",
+		"세션/1_모듈/2_둘째강의.mp4",
+	)
+	h.p.defaultSectionSequence = 3
+	h.run("세션", "세션")
+
+	if got, want := h.mem.sectionTitles(), []string{"기본|3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %v, want %v", got, want)
+	}
+	var got []string
+	for _, c := range h.mem.contentList() {
+		got = append(got, fmt.Sprintf("%s:%d", c.Type, c.Sequence))
+	}
+	want := []string{"lecture:1", "lecture:2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("contents = %v, want %v", got, want)
+	}
+}
+
+func TestNoReuseSecondRunDoublesRows(t *testing.T) {
+	h := newSessionHarness(t, []string{"E1"},
+		"세션/1_함수/0_극한/1_도입.mp4",
+		"세션/1_함수/0_극한/2_정리.mp4",
+		"세션/1_함수/1_연속/1_해설_E1.mp4",
+		"세션/2_미분/0_정의/1_도입.mp4",
+	)
+	// -no-reuse는 NewParser에서 -on-existing=new로 바뀜
+	h.p.onExisting = "new"
+
+	h.run("세션", "세션")
+	first := h.mem.counts()
+	h.run("세션", "세션")
+	second := h.mem.counts()
+
+	for _, table := range []string{"sessions", "modules", "sections", "contents"} {
+		if first[table] == 0 {
+			t.Errorf("%s: first run created no rows", table)
+		}
+		if second[table] != 2*first[table] {
+			t.Errorf("%s: %d rows after two runs, want %d", table, second[table], 2*first[table])
+		}
+	}
+}
+
+func TestReuseSecondRunAddsNoRows(t *testing.T) {
+	h := newSessionHarness(t, []string{"E1"},
+		"세션/1_함수/0_극한/1_도입.mp4",
+		"세션/1_함수/1_연속/1_해설_E1.mp4",
+	)
+
+	h.run("세션", "세션")
+	first := h.mem.counts()
+	h.run("세션", "세션")
+	if second := h.mem.counts(); !reflect.DeepEqual(second, first) {
+		t.Errorf("rows after second run = %v, want unchanged %v", second, first)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestCloseClosesPreparedStatements(t *testing.T) {
+	h := newSessionHarness(t, []string{"E1"},
+		"세션/1_모듈/0_섹션/1_도입.mp4",
+		"세션/1_모듈/0_섹션/2_정리.mp4",
+		"세션/1_모듈/0_섹션/3_예제_해설_E1.mp4",
+	)
+	h.run("세션", "세션")
+	h.run("세션", "세션")
+
+	// 반복되는 확인 쿼리는 한 번만 준비되어 재사용됨
+	stmts := make(map[string]*sql.Stmt)
+	for query, stmt := range h.p.stmts {
+		stmts[query] = stmt
+	}
+	if len(stmts) == 0 {
+		t.Fatal("no prepared statements after processing a section")
+	}
+	for query, stmt := range stmts {
+		again, err := h.p.prepared(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again != stmt {
+			t.Errorf("statement re-prepared: %s", query)
+		}
+	}
+	if h.fake.openStmts() == 0 {
+		t.Fatal("fake driver has no open statements before Close")
+	}
+
+	h.p.Close()
+
+	if len(h.p.stmts) != 0 {
+		t.Errorf("%d statements left in the cache after Close", len(h.p.stmts))
+	}
+	// db.Close만 했다면 "database is closed"가 나옴
+	for query, stmt := range stmts {
+		var id int64
+		err := stmt.QueryRow(1, 2, 3).Scan(&id)
+		if err == nil || !strings.Contains(err.Error(), "statement is closed") {
+			t.Errorf("statement %q after Close: err = %v, want statement is closed", query, err)
+		}
+	}
+	if got := h.fake.openStmts(); got != 0 {
+		t.Errorf("%d driver statements still open after Close", got)
+	}
+}
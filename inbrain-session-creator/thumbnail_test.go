@@ -0,0 +1,129 @@
+package main
+
+import "testing"
+
+func TestThumbnailLocation(t *testing.T) {
+	const videoKey = "lectures/세션/1_모듈/0_섹션/1_도입.mp4"
+	tests := []struct {
+		name    string
+		prefix  string
+		baseURL string
+		wantKey string
+		wantURL string
+	}{
+		{
+			name:    "default next to the video",
+			baseURL: cloudfrontBaseURL,
+			wantKey: "lectures/세션/1_모듈/0_섹션/1_도입_thumbnail.png",
+			wantURL: cloudfrontBaseURL + "/lectures/세션/1_모듈/0_섹션/1_도입_thumbnail.png",
+		},
+		{
+			name:    "prefix and base URL override",
+			prefix:  "cache/v1",
+			baseURL: "https://img.example.com",
+			wantKey: "cache/v1/lectures/세션/1_모듈/0_섹션/1_도입_thumbnail.png",
+			wantURL: "https://img.example.com/cache/v1/lectures/세션/1_모듈/0_섹션/1_도입_thumbnail.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestParser()
+			p.thumbnailPrefix = tt.prefix
+			p.thumbnailBaseURL = tt.baseURL
+
+			key, url := p.thumbnailLocation(videoKey)
+			if key != tt.wantKey {
+				t.Errorf("key = %q, want %q", key, tt.wantKey)
+			}
+			if url != tt.wantURL {
+				t.Errorf("url = %q, want %q", url, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestThumbnailUploadedToOverrideBucket(t *testing.T) {
+	h := newSessionHarness(t, nil, "세션/1_모듈/0_섹션/1_도입.mp4")
+	h.p.thumbnailBucket = "thumbs"
+	h.p.thumbnailPrefix = "cache"
+	h.p.thumbnailBaseURL = "https://img.example.com"
+	h.run("세션", "세션")
+
+	const key = "cache/lectures/세션/1_모듈/0_섹션/1_도입_thumbnail.png"
+	if _, ok := h.stub.object("thumbs", key); !ok {
+		t.Errorf("thumbnail not uploaded to thumbs/%s", key)
+	}
+	if _, ok := h.stub.object("videos", "lectures/세션/1_모듈/0_섹션/1_도입_thumbnail.png"); ok {
+		t.Error("thumbnail uploaded next to the video despite -thumbnail-bucket")
+	}
+
+	inserts := h.fake.find("INSERT INTO videos")
+	if len(inserts) != 1 {
+		t.Fatalf("%d videos inserted, want 1", len(inserts))
+	}
+	_, wantURL := h.p.thumbnailLocation("lectures/세션/1_모듈/0_섹션/1_도입.mp4")
+	if got := inserts[0].Args[3]; got != wantURL {
+		t.Errorf("thumbnail_url = %v, want %s", got, wantURL)
+	}
+}
+
+func TestThumbnailContentType(t *testing.T) {
+	h := newSessionHarness(t, nil, "세션/1_모듈/0_섹션/1_도입.mp4")
+	h.run("세션", "세션")
+
+	obj, ok := h.stub.object("videos", "lectures/세션/1_모듈/0_섹션/1_도입_thumbnail.png")
+	if !ok {
+		t.Fatal("thumbnail not uploaded")
+	}
+	if obj.ContentType != "image/png" {
+		t.Errorf("thumbnail Content-Type = %q, want image/png", obj.ContentType)
+	}
+}
+
+func TestExistingThumbnailIsReused(t *testing.T) {
+	const (
+		videoKey = "lectures/세션/1_모듈/0_섹션/1_도입.mp4"
+		thumbKey = "lectures/세션/1_모듈/0_섹션/1_도입_thumbnail.png"
+	)
+	tests := []struct {
+		name       string
+		existing   bool
+		force      bool
+		wantFrames int
+		wantBody   string
+	}{
+		{name: "no thumbnail yet", existing: false, wantFrames: 1, wantBody: "png\n"},
+		{name: "existing thumbnail reused", existing: true, wantFrames: 0, wantBody: "old"},
+		{name: "-force-thumbnails regenerates", existing: true, force: true, wantFrames: 1, wantBody: "png\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newSessionHarness(t, nil, "세션/1_모듈/0_섹션/1_도입.mp4")
+			frames := fakeTool(t, "ffmpeg", fakeFFmpeg)
+			if tt.existing {
+				h.stub.put("videos", thumbKey, []byte("old"))
+			}
+			h.p.forceThumbnails = tt.force
+			h.run("세션", "세션")
+
+			if got := frames(); got != tt.wantFrames {
+				t.Errorf("ffmpeg ran %d times, want %d", got, tt.wantFrames)
+			}
+			obj, ok := h.stub.object("videos", thumbKey)
+			if !ok {
+				t.Fatal("no thumbnail object")
+			}
+			if string(obj.Body) != tt.wantBody {
+				t.Errorf("thumbnail body = %q, want %q", obj.Body, tt.wantBody)
+			}
+
+			inserts := h.fake.find("INSERT INTO videos")
+			if len(inserts) != 1 {
+				t.Fatalf("%d videos inserted, want 1", len(inserts))
+			}
+			if _, wantURL := h.p.thumbnailLocation(videoKey); inserts[0].Args[3] != wantURL {
+				t.Errorf("thumbnail_url = %v, want %s", inserts[0].Args[3], wantURL)
+			}
+		})
+	}
+}
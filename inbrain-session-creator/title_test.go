@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenderTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{name: "default", template: "{filename}", want: "도입"},
+		{name: "all placeholders", template: "{module} {section} {n}. {filename}", want: "함수 극한 3. 도입"},
+		{name: "repeated placeholder", template: "{n}-{n}", want: "3-3"},
+		{name: "no placeholders", template: "고정 제목", want: "고정 제목"},
+		{name: "unknown placeholder is kept", template: "{filename} {date}", want: "도입 {date}"},
+		{name: "empty", template: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderTitle(tt.template, "함수", "극한", "도입", 3); got != tt.want {
+				t.Errorf("renderTitle(%q) = %q, want %q", tt.template, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitleTemplateNamesVideos(t *testing.T) {
+	h := newSessionHarness(t, nil,
+		"세션/1_함수/0_극한/1_도입.mp4",
+		"세션/1_함수/0_극한/2_정리.mp4",
+	)
+	h.p.titleTemplate = "[{module}/{section}] {n}. {filename}"
+	h.run("세션", "세션")
+
+	var got []string
+	for _, q := range h.fake.find("INSERT INTO videos") {
+		got = append(got, q.Args[1].(string))
+	}
+	want := []string{"[함수/극한] 1. 도입", "[함수/극한] 2. 정리"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("video titles = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTranscodedKey(t *testing.T) {
+	tests := []struct {
+		key    string
+		want   string
+		wantOK bool
+	}{
+		{"lectures/s/m/1_강의.mov", "lectures/s/m/1_강의.mp4", true},
+		{"lectures/s/m/1_강의.MOV", "lectures/s/m/1_강의.mp4", true},
+		{"lectures/s/m/1_강의.mp4", "", false},
+		{"lectures/s/m.mov/1_강의", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := transcodedKey(tt.key)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("transcodedKey(%q) = %q, %v; want %q, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestGetFilesInSectionTranscodedMP4(t *testing.T) {
+	const prefix = "lectures/세션/1_모듈/1_섹션/"
+	tests := []struct {
+		name      string
+		transcode bool
+		want      []string
+	}{
+		{
+			name:      "transcode hides converted mp4",
+			transcode: true,
+			want:      []string{prefix + "1_강의.mov", prefix + "2_강의.mp4", prefix + "3_강의.mov"},
+		},
+		{
+			name:      "without transcode every mp4 is kept",
+			transcode: false,
+			want:      []string{prefix + "1_강의.mov", prefix + "1_강의.mp4", prefix + "2_강의.mp4", prefix + "3_강의.mov"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, client := newS3Stub(t)
+			for _, name := range []string{"1_강의.mov", "1_강의.mp4", "2_강의.mp4", "3_강의.mov", "1_강의_thumbnail.png"} {
+				stub.put("videos", prefix+name, []byte("x"))
+			}
+
+			p := newTestParser()
+			p.s3Client = client
+			p.bucketName = "videos"
+			p.transcode = tt.transcode
+
+			got, err := p.GetFilesInSection("세션", "1_모듈", "1_섹션")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateVideoStoresTranscodedURL(t *testing.T) {
+	const key = "lectures/세션/1_모듈/1_섹션/1_강의 영상.mov"
+	tests := []struct {
+		name          string
+		transcode     bool
+		wantSourceURL string
+	}{
+		{"transcode stores mp4", true, "https://cdn.example/lectures/세션/1_모듈/1_섹션/1_강의%20영상.mp4"},
+		{"without transcode stores mov", false, "https://cdn.example/lectures/세션/1_모듈/1_섹션/1_강의%20영상.mov"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeTool(t, "ffprobe", "echo 42.0")
+			ffmpegCalls := fakeTool(t, "ffmpeg", "exit 1")
+
+			// 이미 변환된 mp4와 썸네일이 있으므로 ffmpeg는 실행되지 않아야 함
+			stub, client := newS3Stub(t)
+			stub.put("videos", strings.TrimSuffix(key, ".mov")+".mp4", []byte("mp4"))
+			stub.put("videos", strings.TrimSuffix(key, ".mov")+"_thumbnail.png", []byte("png"))
+
+			db, fake := openFakeDB(t, func(query string, args []driver.Value) (*fakeResult, error) {
+				if strings.Contains(query, "INSERT INTO videos") {
+					return rows(row(int64(7))), nil
+				}
+				return nil, nil
+			})
+
+			p := newTestParser()
+			p.db = db
+			p.s3Client = client
+			p.bucketName = "videos"
+			p.thumbnailBucket = "videos"
+			p.storedBaseURL = "https://cdn.example"
+			p.thumbnailBaseURL = "https://cdn.example"
+			p.transcode = tt.transcode
+
+			const videoURL = "https://media.example/a.mov"
+			id, err := p.createVideoFromURL("강의", videoURL, key, p.storedSourceKey(videoURL, key), "")
+			if err != nil || id != 7 {
+				t.Fatalf("createVideoFromURL = %d, %v", id, err)
+			}
+			if n := ffmpegCalls(); n != 0 {
+				t.Errorf("ffmpeg called %d times, want 0", n)
+			}
+
+			inserts := fake.find("INSERT INTO videos")
+			if len(inserts) != 1 {
+				t.Fatalf("video inserts = %d, want 1", len(inserts))
+			}
+			args := inserts[0].Args
+			if args[2] != tt.wantSourceURL {
+				t.Errorf("source_url = %v, want %s", args[2], tt.wantSourceURL)
+			}
+			// 썸네일과 길이는 항상 원본 기준
+			if want := "https://cdn.example/lectures/세션/1_모듈/1_섹션/1_강의%20영상_thumbnail.png"; args[3] != want {
+				t.Errorf("thumbnail_url = %v, want %s", args[3], want)
+			}
+			if args[4] != int64(42) {
+				t.Errorf("max_progress = %v, want 42", args[4])
+			}
+		})
+	}
+}
+
+func TestTranscodeRunsOnceAcrossRetries(t *testing.T) {
+	const key = "lectures/세션/1_모듈/1_섹션/1_강의.mov"
+	tests := []struct {
+		name          string
+		ffmpeg        string
+		wantFallbacks int
+	}{
+		{"transcode succeeds", `for a in "$@"; do out=$a; done
+echo mp4 > "$out"`, 0},
+		{"transcode fails", "echo 'No space left on device' >&2\nexit 1", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probeCalls := fakeTool(t, "ffprobe", "echo 'moov atom not found' >&2\nexit 1")
+			ffmpegCalls := fakeTool(t, "ffmpeg", tt.ffmpeg)
+			saved := httpClient
+			httpClient = &http.Client{Transport: offlineTransport{}}
+			t.Cleanup(func() { httpClient = saved })
+
+			stub, client := newS3Stub(t)
+			stub.put("videos", key, []byte("mov"))
+
+			p := newTestParser()
+			p.s3Client = client
+			p.bucketName = "videos"
+			p.transcode = true
+			p.maxRetriesPerFile = 2
+			p.fileRetryDelay = time.Millisecond
+
+			if _, err := p.createVideoWithRetry("강의", "https://media.example/a.mov", key); err == nil {
+				t.Fatal("createVideoWithRetry succeeded, want ffprobe failure")
+			}
+			if got := probeCalls(); got != 3 {
+				t.Errorf("ffprobe called %d times, want 3", got)
+			}
+			if got := ffmpegCalls(); got != 1 {
+				t.Errorf("ffmpeg called %d times, want 1 (transcode only, not per retry)", got)
+			}
+			if got := stub.count("PUT"); got != 1-tt.wantFallbacks {
+				t.Errorf("%d PUTs, want %d", got, 1-tt.wantFallbacks)
+			}
+			fallbacks := 0
+			for _, f := range p.failedFiles {
+				if f.Fallback {
+					fallbacks++
+				}
+			}
+			if fallbacks != tt.wantFallbacks {
+				t.Errorf("fallback entries = %d, want %d", fallbacks, tt.wantFallbacks)
+			}
+		})
+	}
+}
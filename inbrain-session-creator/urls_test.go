@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestStoredBaseURLOverridesOnlyStoredURLs(t *testing.T) {
+	h := newSessionHarness(t, nil, "세션/1_모듈/0_섹션/1_도입.mp4")
+	probeArgs := recordToolArgs(t, "ffprobe", "echo 30.0")
+	frameArgs := recordToolArgs(t, "ffmpeg", fakeFFmpeg)
+	h.p.storedBaseURL = "https://new-cdn.example.com"
+	h.p.thumbnailBaseURL = h.p.storedBaseURL
+	h.run("세션", "세션")
+
+	const key = "lectures/세션/1_모듈/0_섹션/1_도입.mp4"
+	// 리다이렉트 확인을 거친 URL이라 경로가 퍼센트 인코딩됨
+	readURL, err := url.Parse(cloudfrontBaseURL + "/" + key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	probes := probeArgs()
+	if len(probes) != 1 || !strings.HasSuffix(probes[0], " "+readURL.String()) {
+		t.Errorf("ffprobe args = %q, want the %s URL", probes, cloudfrontBaseURL)
+	}
+	frames := frameArgs()
+	if len(frames) != 1 || !strings.Contains(frames[0], "-i "+readURL.String()+" ") {
+		t.Errorf("ffmpeg args = %q, want the %s URL", frames, cloudfrontBaseURL)
+	}
+
+	inserts := h.fake.find("INSERT INTO videos")
+	if len(inserts) != 1 {
+		t.Fatalf("%d videos inserted, want 1", len(inserts))
+	}
+	if got, want := inserts[0].Args[2], "https://new-cdn.example.com/"+key; got != want {
+		t.Errorf("source_url = %v, want %s", got, want)
+	}
+	if got, want := inserts[0].Args[3], "https://new-cdn.example.com/lectures/세션/1_모듈/0_섹션/1_도입_thumbnail.png"; got != want {
+		t.Errorf("thumbnail_url = %v, want %s", got, want)
+	}
+}
+
+func TestProbeViaS3UsesPresignedURL(t *testing.T) {
+	h := newSessionHarness(t, nil, "세션/1_모듈/0_섹션/1_도입.mp4")
+	probeArgs := recordToolArgs(t, "ffprobe", "echo 30.0")
+	frameArgs := recordToolArgs(t, "ffmpeg", fakeFFmpeg)
+	h.p.probeViaS3 = true
+	h.run("세션", "세션")
+
+	isPresigned := func(args string) bool {
+		return strings.Contains(args, "/videos/lectures/") && strings.Contains(args, "X-Amz-Signature=") &&
+			!strings.Contains(args, cloudfrontBaseURL)
+	}
+	if probes := probeArgs(); len(probes) != 1 || !isPresigned(probes[0]) {
+		t.Errorf("ffprobe args = %q, want a presigned S3 URL", probes)
+	}
+	if frames := frameArgs(); len(frames) != 1 || !isPresigned(frames[0]) {
+		t.Errorf("ffmpeg args = %q, want a presigned S3 URL", frames)
+	}
+
+	inserts := h.fake.find("INSERT INTO videos")
+	if len(inserts) != 1 {
+		t.Fatalf("%d videos inserted, want 1", len(inserts))
+	}
+	if got, want := inserts[0].Args[2], cloudfrontBaseURL+"/lectures/세션/1_모듈/0_섹션/1_도입.mp4"; got != want {
+		t.Errorf("source_url = %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetFilesInSectionSkipsZeroByteObjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		section string
+		objects map[string]string
+		want    []string
+	}{
+		{
+			name:    "section folder",
+			section: "0_섹션",
+			objects: map[string]string{
+				"lectures/세션/1_모듈/0_섹션/1_도입.mp4": "video",
+				"lectures/세션/1_모듈/0_섹션/2_정리.mp4": "",
+				"lectures/세션/1_모듈/0_섹션/3_예제.mp4": "video",
+			},
+			want: []string{"lectures/세션/1_모듈/0_섹션/1_도입.mp4", "lectures/세션/1_모듈/0_섹션/3_예제.mp4"},
+		},
+		{
+			name: "loose files",
+			objects: map[string]string{
+				"lectures/세션/1_모듈/1_도입.mp4": "",
+				"lectures/세션/1_모듈/2_정리.mp4": "video",
+			},
+			want: []string{"lectures/세션/1_모듈/2_정리.mp4"},
+		},
+		{
+			name: "all zero bytes",
+			objects: map[string]string{
+				"lectures/세션/1_모듈/1_도입.mp4": "",
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, client := newS3Stub(t)
+			for key, body := range tt.objects {
+				stub.put("videos", key, []byte(body))
+			}
+			p := newTestParser()
+			p.s3Client = client
+			p.bucketName = "videos"
+
+			got, err := p.GetFilesInSection("세션", "1_모듈", tt.section)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZeroByteObjectCreatesNoVideo(t *testing.T) {
+	h := newSessionHarness(t, nil,
+		"세션/1_모듈/0_섹션/1_도입.mp4",
+		"세션/1_모듈/0_섹션/2_정리.mp4",
+	)
+	h.stub.put("videos", "lectures/세션/1_모듈/0_섹션/2_정리.mp4", nil)
+	probes := fakeTool(t, "ffprobe", "echo 0.0")
+	h.run("세션", "세션")
+
+	if got := probes(); got != 1 {
+		t.Errorf("ffprobe ran %d times, want 1 (zero-byte object skipped)", got)
+	}
+	if got := h.mem.counts()["videos"]; got != 1 {
+		t.Errorf("%d videos created, want 1", got)
+	}
+}
@@ -1,28 +1,65 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"mime"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"golang.org/x/text/unicode/norm"
+
+	"github.com/unboxerscorp/utility/common/loglevel"
 )
 
 func main() {
-	if len(os.Args) != 3 {
-		fmt.Println("Usage: go run main.go '<local-folder>' '<s3-path>'")
+	var region string
+	var endpoint string
+	var manifestPath string
+	var logLevel string
+	var maxBandwidth int64
+	var verify bool
+	var dryRun bool
+	flag.StringVar(&region, "region", "", "AWS region of the bucket (default: from AWS config/env)")
+	flag.StringVar(&endpoint, "endpoint", "", "Custom S3 endpoint URL (e.g. LocalStack/MinIO: http://localhost:4566)")
+	flag.StringVar(&manifestPath, "manifest", "", "File recording uploaded keys; keys already listed are skipped on re-run")
+	flag.Int64Var(&maxBandwidth, "max-bandwidth", 0, "Upload bandwidth cap in bytes/sec shared by all uploads (0 = unlimited)")
+	flag.BoolVar(&verify, "verify", false, "After uploading, list the destination prefix and fail if any local file is missing or has a different size")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the local file -> S3 key plan without uploading anything")
+	flag.StringVar(&logLevel, "log-level", "info", "Progress output level ("+loglevel.Names+"); warn hides per-file lines")
+	flag.Parse()
+
+	if err := loglevel.Set(logLevel); err != nil {
+		log.Fatal(err)
+	}
+	loglevel.SetOutput(func(format string, args ...any) {
+		fmt.Printf(format, args...)
+	})
+
+	if maxBandwidth < 0 {
+		log.Fatal("-max-bandwidth must be 0 (unlimited) or a positive number of bytes/sec")
+	}
+
+	if flag.NArg() != 2 {
+		fmt.Println("Usage: go run main.go [-region=ap-northeast-2] [-endpoint=http://localhost:4566] [-manifest=upload.manifest] [-max-bandwidth=0] [-verify] [-dry-run] [-log-level=info] '<local-folder>' '<s3-path>'")
 		fmt.Println("Example: go run main.go './공수 1강' 'base-inbrain-resource/lectures/'")
 		os.Exit(1)
 	}
 
-	localFolder := os.Args[1]
-	s3Path := os.Args[2]
+	localFolder := flag.Arg(0)
+	s3Path := flag.Arg(1)
 
 	// Parse S3 path (bucket/prefix)
 	parts := strings.SplitN(s3Path, "/", 2)
@@ -37,15 +74,106 @@ func main() {
 	}
 
 	// Initialize AWS config
-	cfg, err := config.LoadDefaultConfig(context.Background())
+	var loadOpts []func(*config.LoadOptions) error
+	if region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(region))
+	}
+	cfg, err := config.LoadDefaultConfig(context.Background(), loadOpts...)
 	if err != nil {
 		log.Fatalf("Failed to load AWS config: %v", err)
 	}
 
-	client := s3.NewFromConfig(cfg)
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
+			// LocalStack/MinIO need path-style addressing
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	// Load keys uploaded by a previous run
+	uploaded := make(map[string]bool)
+	var manifest *os.File
+	if manifestPath != "" {
+		uploaded, err = loadManifest(manifestPath)
+		if err != nil {
+			log.Fatalf("Failed to read manifest: %v", err)
+		}
+		fmt.Printf("Loaded %d uploaded keys from manifest %s\n", len(uploaded), manifestPath)
+
+		// A dry run must not create or append to the manifest
+		if !dryRun {
+			manifest, err = openManifest(manifestPath)
+			if err != nil {
+				log.Fatalf("Failed to open manifest: %v", err)
+			}
+			defer func() {
+				_ = manifest.Close()
+			}()
+		}
+	}
+
+	var limiter *bandwidthLimiter
+	if maxBandwidth > 0 {
+		limiter = newBandwidthLimiter(maxBandwidth)
+		fmt.Printf("Limiting upload bandwidth to %d bytes/sec\n", maxBandwidth)
+	}
+
+	upload := &folderUpload{
+		client:   client,
+		bucket:   bucket,
+		prefix:   prefix,
+		uploaded: uploaded,
+		limiter:  limiter,
+		dryRun:   dryRun,
+		plan:     os.Stdout,
+	}
+	// A nil *os.File must not end up in the io.Writer field
+	if manifest != nil {
+		upload.manifest = manifest
+	}
+	expected, skipped, err := upload.run(localFolder)
+	if err != nil {
+		log.Fatalf("Error walking directory: %v", err)
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d files, %d already in manifest; nothing was uploaded\n", len(expected), skipped)
+		return
+	}
+
+	if skipped > 0 {
+		fmt.Printf("Skipped %d files already in manifest\n", skipped)
+	}
+	fmt.Println("Upload completed successfully!")
+
+	if verify {
+		if !verifyFolder(client, bucket, folderPrefix(prefix, localFolder), expected) {
+			os.Exit(1)
+		}
+	}
+}
+
+// folderUpload uploads the files of one local folder to bucket/prefix.
+type folderUpload struct {
+	client   *s3.Client
+	bucket   string
+	prefix   string
+	uploaded map[string]bool // "bucket/key" lines loaded from the manifest
+	manifest io.Writer       // nil when -manifest is not set or on a dry run
+	limiter  *bandwidthLimiter
+	dryRun   bool
+	plan     io.Writer // where the -dry-run plan is printed
+}
+
+// run walks localFolder recursively and uploads every file that is not already in the manifest.
+// Returns the local size of every key under the folder (for -verify) and the number of manifest skips.
+func (u *folderUpload) run(localFolder string) (map[string]int64, int, error) {
+	skipped := 0
+	// Local size of every key under the folder, for -verify (includes keys skipped via the manifest)
+	expected := make(map[string]int64)
 
-	// Walk through local folder recursively
-	err = filepath.Walk(localFolder, func(path string, info os.FileInfo, err error) error {
+	err := filepath.Walk(localFolder, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -63,7 +191,7 @@ func main() {
 
 		// Get folder name and include it in the path
 		folderName := filepath.Base(localFolder)
-		
+
 		// Convert path separators to forward slashes for S3
 		s3Key := filepath.ToSlash(filepath.Join(folderName, relPath))
 
@@ -71,12 +199,30 @@ func main() {
 		s3Key = norm.NFC.String(s3Key)
 
 		// Add prefix if provided
-		if prefix != "" {
-			s3Key = prefix + s3Key
+		if u.prefix != "" {
+			s3Key = u.prefix + s3Key
+		}
+
+		expected[s3Key] = info.Size()
+
+		manifestKey := u.bucket + "/" + s3Key
+		if u.dryRun {
+			note := ""
+			if u.uploaded[manifestKey] {
+				note = " (already in manifest, would skip)"
+				skipped++
+			}
+			fmt.Fprintf(u.plan, "%s -> s3://%s/%s%s\n", path, u.bucket, s3Key, note)
+			return nil
+		}
+		if u.uploaded[manifestKey] {
+			loglevel.Infof("Skipping %s (already in manifest)\n", s3Key)
+			skipped++
+			return nil
 		}
 
 		// Upload file to S3
-		fmt.Printf("Uploading %s to s3://%s/%s\n", path, bucket, s3Key)
+		loglevel.Infof("Uploading %s to s3://%s/%s\n", path, u.bucket, s3Key)
 
 		file, err := os.Open(path)
 		if err != nil {
@@ -86,22 +232,226 @@ func main() {
 			_ = file.Close()
 		}()
 
-		_, err = client.PutObject(context.TODO(), &s3.PutObjectInput{
-			Bucket: aws.String(bucket),
-			Key:    aws.String(s3Key),
-			Body:   file,
+		var body io.ReadSeeker = file
+		if u.limiter != nil {
+			body = &throttledReader{file: file, limiter: u.limiter}
+		}
+
+		_, err = u.client.PutObject(context.TODO(), &s3.PutObjectInput{
+			Bucket:        aws.String(u.bucket),
+			Key:           aws.String(s3Key),
+			Body:          body,
+			ContentLength: aws.Int64(info.Size()),
+			ContentType:   contentTypeFor(path),
 		})
 		if err != nil {
 			return fmt.Errorf("failed to upload %s: %v", path, err)
 		}
 
-		fmt.Printf("Successfully uploaded %s\n", s3Key)
+		// Catch truncated uploads before the key goes into the manifest
+		if err := verifyUploadedSize(u.client, u.bucket, s3Key, info.Size()); err != nil {
+			return fmt.Errorf("failed to verify %s: %v", path, err)
+		}
+
+		loglevel.Infof("Successfully uploaded %s\n", s3Key)
+
+		// Record right away so an interrupted run keeps its progress
+		if u.manifest != nil {
+			if _, err := fmt.Fprintln(u.manifest, manifestKey); err != nil {
+				return fmt.Errorf("failed to write manifest: %v", err)
+			}
+		}
 		return nil
 	})
+	return expected, skipped, err
+}
+
+// folderPrefix is the key prefix under which the walk places every file of localFolder.
+func folderPrefix(prefix, localFolder string) string {
+	folderName := filepath.Base(localFolder)
+	if folderName == "." || folderName == string(filepath.Separator) {
+		return prefix
+	}
+	return prefix + norm.NFC.String(folderName) + "/"
+}
 
+// verifyFolder lists listPrefix and reports every expected key that is missing or has a different size.
+// Returns false if any problem was found (or the listing failed).
+func verifyFolder(client *s3.Client, bucket, listPrefix string, expected map[string]int64) bool {
+	fmt.Printf("Verifying %d files under s3://%s/%s\n", len(expected), bucket, listPrefix)
+
+	sizes := make(map[string]int64)
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(listPrefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			fmt.Printf("Verify failed: could not list s3://%s/%s: %v\n", bucket, listPrefix, err)
+			return false
+		}
+		for _, obj := range page.Contents {
+			sizes[aws.ToString(obj.Key)] = aws.ToInt64(obj.Size)
+		}
+	}
+
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	missing, mismatched := 0, 0
+	for _, key := range keys {
+		got, ok := sizes[key]
+		switch {
+		case !ok:
+			fmt.Printf("MISSING  %s\n", key)
+			missing++
+		case got != expected[key]:
+			fmt.Printf("SIZE     %s (s3: %d bytes, local: %d bytes)\n", key, got, expected[key])
+			mismatched++
+		}
+	}
+
+	if missing > 0 || mismatched > 0 {
+		fmt.Printf("Verify failed: %d missing, %d size mismatches\n", missing, mismatched)
+		return false
+	}
+	fmt.Printf("Verified %d files\n", len(keys))
+	return true
+}
+
+// bandwidthLimiter paces reads so that all uploads together stay under bytesPerSec.
+// It keeps the time at which the next byte may be sent and has no burst allowance.
+type bandwidthLimiter struct {
+	mu          sync.Mutex
+	bytesPerSec int64
+	next        time.Time
+}
+
+func newBandwidthLimiter(bytesPerSec int64) *bandwidthLimiter {
+	return &bandwidthLimiter{bytesPerSec: bytesPerSec}
+}
+
+// chunkSize keeps each read to about 1/10s of the budget so the pacing stays smooth.
+func (l *bandwidthLimiter) chunkSize() int {
+	size := l.bytesPerSec / 10
+	if size < 1 {
+		size = 1
+	}
+	if size > 64<<10 {
+		size = 64 << 10
+	}
+	return int(size)
+}
+
+// wait reserves n bytes and sleeps until the previous reservations have been paid off.
+func (l *bandwidthLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.bytesPerSec))
+	l.mu.Unlock()
+
+	time.Sleep(delay)
+}
+
+// throttledReader exposes only Read and Seek of the file so the SDK can still rewind on retry
+// but cannot bypass the limiter through io.WriterTo/ReaderAt.
+type throttledReader struct {
+	file    *os.File
+	limiter *bandwidthLimiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if chunk := r.limiter.chunkSize(); len(p) > chunk {
+		p = p[:chunk]
+	}
+	n, err := r.file.Read(p)
+	if n > 0 {
+		r.limiter.wait(n)
+	}
+	return n, err
+}
+
+func (r *throttledReader) Seek(offset int64, whence int) (int64, error) {
+	return r.file.Seek(offset, whence)
+}
+
+// verifyUploadedSize checks with HeadObject that the stored object has the local file's size.
+func verifyUploadedSize(client *s3.Client, bucket, key string, size int64) error {
+	head, err := client.HeadObject(context.TODO(), &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
 	if err != nil {
-		log.Fatalf("Error walking directory: %v", err)
+		return err
+	}
+	if got := aws.ToInt64(head.ContentLength); got != size {
+		return fmt.Errorf("uploaded object is %d bytes, local file is %d bytes", got, size)
 	}
+	return nil
+}
 
-	fmt.Println("Upload completed successfully!")
+// videoContentTypes covers video extensions that the mime package may not know on minimal systems.
+var videoContentTypes = map[string]string{
+	".mov": "video/quicktime",
+	".mp4": "video/mp4",
+	".m4v": "video/x-m4v",
+}
+
+// contentTypeFor derives the Content-Type from the file extension so browsers render uploads inline.
+// Returns nil (S3 default) for unknown extensions.
+func contentTypeFor(path string) *string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if contentType, ok := videoContentTypes[ext]; ok {
+		return aws.String(contentType)
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		return aws.String(contentType)
+	}
+	return nil
+}
+
+// openManifest opens the manifest for appending, creating it if needed.
+func openManifest(path string) (*os.File, error) {
+	// Reject relative path traversal
+	if strings.Contains(path, "..") {
+		return nil, errors.New("invalid file path: relative path not allowed")
+	}
+	return os.OpenFile(filepath.Clean(path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+}
+
+// loadManifest reads "bucket/key" lines written by a previous run. A missing file is an empty manifest.
+func loadManifest(path string) (map[string]bool, error) {
+	keys := make(map[string]bool)
+
+	// Reject relative path traversal
+	if strings.Contains(path, "..") {
+		return nil, errors.New("invalid file path: relative path not allowed")
+	}
+
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return keys, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			keys[line] = true
+		}
+	}
+	return keys, scanner.Err()
 }
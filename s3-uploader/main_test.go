@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// writeFolder creates a folder named "lectures" with the given relative files and returns its path.
+func writeFolder(t *testing.T, files map[string]string) string {
+	t.Helper()
+	folder := filepath.Join(t.TempDir(), "lectures")
+	for name, body := range files {
+		path := filepath.Join(folder, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return folder
+}
+
+// uploadWithManifest runs one upload the way main does with -manifest=manifestPath.
+func uploadWithManifest(t *testing.T, upload *folderUpload, folder, manifestPath string) (map[string]int64, int) {
+	t.Helper()
+	uploaded, err := loadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("loadManifest: %v", err)
+	}
+	manifest, err := openManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("openManifest: %v", err)
+	}
+	defer func() {
+		_ = manifest.Close()
+	}()
+
+	upload.uploaded = uploaded
+	upload.manifest = manifest
+	expected, skipped, err := upload.run(folder)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	return expected, skipped
+}
+
+func TestManifestSkipsUploadedKeysOnRerun(t *testing.T) {
+	stub, client := newS3Stub(t)
+	folder := writeFolder(t, map[string]string{
+		"1_intro.mp4":     "intro",
+		"unit/2_body.mov": "body",
+		"unit/notes.txt":  "notes",
+	})
+	manifestPath := filepath.Join(t.TempDir(), "upload.manifest")
+	upload := &folderUpload{client: client, bucket: "videos", prefix: "base/"}
+
+	expected, skipped := uploadWithManifest(t, upload, folder, manifestPath)
+	if len(expected) != 3 || skipped != 0 {
+		t.Fatalf("first run: %d files, %d skipped; want 3, 0", len(expected), skipped)
+	}
+	if got := stub.count("PUT"); got != 3 {
+		t.Fatalf("first run: %d PUTs, want 3", got)
+	}
+	for key := range expected {
+		if _, ok := stub.object("videos", key); !ok {
+			t.Errorf("first run did not upload %s", key)
+		}
+	}
+
+	expected, skipped = uploadWithManifest(t, upload, folder, manifestPath)
+	if len(expected) != 3 || skipped != 3 {
+		t.Errorf("second run: %d files, %d skipped; want 3, 3", len(expected), skipped)
+	}
+	if got := stub.count("PUT"); got != 3 {
+		t.Errorf("second run uploaded %d files, want none", got-3)
+	}
+
+	keys, err := loadManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 {
+		t.Errorf("manifest has %d keys after two runs, want 3", len(keys))
+	}
+}
+
+func TestContentTypeFor(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"lectures/1_intro.mp4", "video/mp4"},
+		{"lectures/1_intro.MOV", "video/quicktime"},
+		{"lectures/1_intro.m4v", "video/x-m4v"},
+		{"lectures/1_intro_thumbnail.png", "image/png"},
+		{"lectures/notes", ""},
+		{"lectures/1_intro.unknownext", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := aws.ToString(contentTypeFor(tt.path))
+			if got != tt.want {
+				t.Errorf("contentTypeFor(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUploadSetsContentType(t *testing.T) {
+	stub, client := newS3Stub(t)
+	folder := writeFolder(t, map[string]string{
+		"1_intro.mp4":   "video",
+		"2_body.mov":    "video",
+		"thumbnail.png": "png",
+	})
+	upload := &folderUpload{client: client, bucket: "videos"}
+	if _, _, err := upload.run(folder); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"lectures/1_intro.mp4":   "video/mp4",
+		"lectures/2_body.mov":    "video/quicktime",
+		"lectures/thumbnail.png": "image/png",
+	} {
+		obj, ok := stub.object("videos", key)
+		if !ok {
+			t.Errorf("%s not uploaded", key)
+			continue
+		}
+		if obj.ContentType != want {
+			t.Errorf("%s Content-Type = %q, want %q", key, obj.ContentType, want)
+		}
+	}
+}
+
+func TestVerifyUploadedSize(t *testing.T) {
+	stub, client := newS3Stub(t)
+	stub.put("videos", "lectures/1_intro.mp4", []byte("video"))
+	stub.put("videos", "lectures/empty.mp4", nil)
+
+	tests := []struct {
+		name    string
+		key     string
+		size    int64
+		wantErr bool
+	}{
+		{name: "matching size", key: "lectures/1_intro.mp4", size: 5},
+		{name: "truncated object", key: "lectures/1_intro.mp4", size: 6, wantErr: true},
+		{name: "zero-byte object", key: "lectures/empty.mp4", size: 5, wantErr: true},
+		{name: "zero-byte local file", key: "lectures/empty.mp4", size: 0},
+		{name: "missing object", key: "lectures/missing.mp4", size: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyUploadedSize(client, "videos", tt.key, tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyUploadedSize(%s, %d) = %v, wantErr %v", tt.key, tt.size, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTruncatedUploadIsNotRecorded(t *testing.T) {
+	stub, client := newS3Stub(t)
+	stub.truncate = true
+	folder := writeFolder(t, map[string]string{"1_intro.mp4": "video"})
+	manifestPath := filepath.Join(t.TempDir(), "upload.manifest")
+
+	manifest, err := openManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upload := &folderUpload{client: client, bucket: "videos", manifest: manifest}
+	_, _, err = upload.run(folder)
+	_ = manifest.Close()
+	if err == nil || !strings.Contains(err.Error(), "failed to verify") {
+		t.Fatalf("run error = %v, want a size verification failure", err)
+	}
+
+	keys, err := loadManifest(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("manifest recorded %v for a truncated upload", keys)
+	}
+}
+
+func TestDryRunPrintsPlanWithoutUploading(t *testing.T) {
+	stub, client := newS3Stub(t)
+	// "강의" written in NFD, the way macOS stores Hangul file names
+	nfd := "\u1100\u1161\u11bc\u110b\u1174"
+	folder := writeFolder(t, map[string]string{
+		"1_intro.mp4":            "intro",
+		"unit/2_" + nfd + ".mp4": "body",
+	})
+	var plan bytes.Buffer
+	upload := &folderUpload{
+		client:   client,
+		bucket:   "videos",
+		prefix:   "base/",
+		uploaded: map[string]bool{"videos/base/lectures/1_intro.mp4": true},
+		dryRun:   true,
+		plan:     &plan,
+	}
+
+	expected, skipped, err := upload.run(folder)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(expected) != 2 || skipped != 1 {
+		t.Errorf("dry run: %d files, %d skipped; want 2, 1", len(expected), skipped)
+	}
+	if got := len(stub.requests); got != 0 {
+		t.Errorf("dry run sent %d requests to S3, want none", got)
+	}
+
+	want := []string{
+		filepath.Join(folder, "1_intro.mp4") + " -> s3://videos/base/lectures/1_intro.mp4 (already in manifest, would skip)",
+		filepath.Join(folder, "unit", "2_"+nfd+".mp4") + " -> s3://videos/base/lectures/unit/2_강의.mp4",
+	}
+	got := strings.Split(strings.TrimSuffix(plan.String(), "\n"), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("plan has %d lines, want %d:\n%s", len(got), len(want), plan.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("plan line %d:\n got %q\nwant %q", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// s3Stub is an in-memory S3 that serves path-style ListObjectsV2, HeadObject, GetObject and PutObject.
+type s3Stub struct {
+	mu       sync.Mutex
+	objects  map[string]s3StubObject // "bucket/key"
+	requests []string                // "METHOD bucket/key"
+	// truncate stores only the first byte of every PUT, like an interrupted upload
+	truncate bool
+}
+
+type s3StubObject struct {
+	Body        []byte
+	ContentType string
+}
+
+// newS3Stub starts the stub server and returns a client pointed at it.
+func newS3Stub(t *testing.T) (*s3Stub, *s3.Client) {
+	t.Helper()
+	stub := &s3Stub{objects: make(map[string]s3StubObject)}
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	client := s3.New(s3.Options{
+		BaseEndpoint:               aws.String(server.URL),
+		UsePathStyle:               true,
+		Region:                     "us-east-1",
+		Credentials:                aws.CredentialsProviderFunc(stubCredentials),
+		RequestChecksumCalculation: aws.RequestChecksumCalculationWhenRequired,
+		RetryMaxAttempts:           1,
+	})
+	return stub, client
+}
+
+// stubCredentials are fixed fake credentials so requests can be signed.
+func stubCredentials(context.Context) (aws.Credentials, error) {
+	return aws.Credentials{AccessKeyID: "AKIDTEST", SecretAccessKey: "secret", Source: "s3stub"}, nil
+}
+
+// put stores an object ahead of the test.
+func (s *s3Stub) put(bucket, key string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[bucket+"/"+key] = s3StubObject{Body: body}
+}
+
+func (s *s3Stub) object(bucket, key string) (s3StubObject, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.objects[bucket+"/"+key]
+	return obj, ok
+}
+
+// count returns the number of requests with method (e.g. "PUT", "HEAD").
+func (s *s3Stub) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if strings.HasPrefix(r, method+" ") {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+bucket+"/"+key)
+	s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodGet && key == "" && r.URL.Query().Get("list-type") == "2":
+		s.list(w, bucket, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"))
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		obj, ok := s.object(bucket, key)
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				_, _ = io.WriteString(w, "<Error><Code>NoSuchKey</Code></Error>")
+			}
+			return
+		}
+		w.Header().Set("ETag", fmt.Sprintf("\"%x\"", md5.Sum(obj.Body))) //nolint:gosec
+		w.Header().Set("Content-Length", fmt.Sprint(len(obj.Body)))
+		if obj.ContentType != "" {
+			w.Header().Set("Content-Type", obj.ContentType)
+		}
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.Body)
+		}
+	case r.Method == http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		if s.truncate && len(body) > 1 {
+			body = body[:1]
+		}
+		s.objects[bucket+"/"+key] = s3StubObject{Body: body, ContentType: r.Header.Get("Content-Type")}
+		s.mu.Unlock()
+		w.Header().Set("ETag", fmt.Sprintf("\"%x\"", md5.Sum(body))) //nolint:gosec
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+type s3StubListResult struct {
+	XMLName        xml.Name `xml:"ListBucketResult"`
+	Name           string
+	Prefix         string
+	KeyCount       int
+	IsTruncated    bool
+	Contents       []s3StubListObject
+	CommonPrefixes []s3StubPrefix
+}
+
+type s3StubListObject struct {
+	Key  string
+	Size int
+}
+
+type s3StubPrefix struct {
+	Prefix string
+}
+
+func (s *s3Stub) list(w http.ResponseWriter, bucket, prefix, delimiter string) {
+	s.mu.Lock()
+	var keys []string
+	sizes := make(map[string]int)
+	for name, obj := range s.objects {
+		b, key, _ := strings.Cut(name, "/")
+		if b == bucket && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+			sizes[key] = len(obj.Body)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(keys)
+
+	result := s3StubListResult{Name: bucket, Prefix: prefix}
+	seenPrefixes := make(map[string]bool)
+	for _, key := range keys {
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				common := key[:len(prefix)+i+len(delimiter)]
+				if !seenPrefixes[common] {
+					seenPrefixes[common] = true
+					result.CommonPrefixes = append(result.CommonPrefixes, s3StubPrefix{Prefix: common})
+				}
+				continue
+			}
+		}
+		result.Contents = append(result.Contents, s3StubListObject{Key: key, Size: sizes[key]})
+	}
+	result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}